@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/services/interfaces"
+
+	"github.com/labstack/echo/v5"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// Idempotent makes a POST endpoint safe to retry when the client sends an
+// Idempotency-Key header. Keys are scoped to the caller token and the route.
+// Only successful responses are stored, so a failed request can be retried
+// with the same key.
+func Idempotent(service interfaces.IIdempotencyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			idempotencyKey := c.Request().Header.Get(IdempotencyKeyHeader)
+			if idempotencyKey == "" {
+				return next(c)
+			}
+			if len(idempotencyKey) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, map[string]string{"message": "Idempotency-Key must not be longer than 255 characters"})
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"message": "Failed to read request data"})
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			key := hashIdempotencyParts(c.Request().Header.Get("auth"), c.Request().Method, c.Path(), idempotencyKey)
+			requestHash := hashIdempotencyParts(string(body))
+
+			stored, err := service.Begin(key, requestHash)
+			if errors.Is(err, my_models.ErrIdempotencyKeyReused) || errors.Is(err, my_models.ErrIdempotencyKeyInProgress) {
+				return c.JSON(http.StatusConflict, map[string]string{"message": err.Error()})
+			}
+			if err != nil {
+				logger.Error("Controller: Error checking idempotency key: ", err)
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{"message": "Idempotency-Key could not be checked, try again later"})
+			}
+			if stored != nil {
+				c.Response().Header().Set(IdempotentReplayedHeader, "true")
+				return c.Blob(stored.StatusCode, stored.ContentType, []byte(stored.Body))
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+			defer func() {
+				// A panicking handler must not keep the key locked until it expires
+				if recovered := recover(); recovered != nil {
+					service.Release(key)
+					panic(recovered)
+				}
+			}()
+
+			err = next(c)
+
+			status := c.Response().Status
+			if err == nil && status >= http.StatusOK && status < http.StatusMultipleChoices {
+				contentType := c.Response().Header().Get(echo.HeaderContentType)
+				if err := service.Complete(key, requestHash, status, contentType, recorder.body.Bytes()); err != nil {
+					logger.Error("Controller: Error storing idempotent response: ", err)
+				}
+			} else if err := service.Release(key); err != nil {
+				logger.Error("Controller: Error releasing idempotency key: ", err)
+			}
+
+			return err
+		}
+	}
+}
+
+// responseRecorder keeps a copy of the response body while it is written.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func hashIdempotencyParts(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"pocketbase_go/logger"
+	"pocketbase_go/services"
+	"pocketbase_go/services/mocks"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+)
+
+func TestMain(m *testing.M) {
+	// The logger writes to ./log, keep it out of the source tree
+	dir, err := os.MkdirTemp("", "controllers")
+	if err == nil && os.Chdir(dir) == nil {
+		logger.Initialize("controllers_test.log")
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newIdempotentRoute serves POST /reservations behind the Idempotent middleware,
+// answering with status and counting how many times the handler ran.
+func newIdempotentRoute(status *int, calls *int) *echo.Echo {
+	e := echo.New()
+	service := &services.IdempotencyService{Repo: mocks.NewMockIdempotencyRepo()}
+	e.POST("/reservations", func(c echo.Context) error {
+		*calls++
+		return c.JSON(*status, map[string]int{"call": *calls})
+	}, Idempotent(service))
+	return e
+}
+
+func postIdempotent(e *echo.Echo, key string, token string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(body))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set("auth", token)
+	if key != "" {
+		request.Header.Set(IdempotencyKeyHeader, key)
+	}
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotentReplaysResponse(t *testing.T) {
+	status, calls := http.StatusCreated, 0
+	e := newIdempotentRoute(&status, &calls)
+
+	first := postIdempotent(e, "key", "token", `{"property":"p1"}`)
+	replay := postIdempotent(e, "key", "token", `{"property":"p1"}`)
+
+	if calls != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", calls)
+	}
+	if replay.Code != http.StatusCreated || replay.Body.String() != first.Body.String() {
+		t.Errorf("expected the original response, got %d %s", replay.Code, replay.Body.String())
+	}
+	if replay.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("expected the replay to be flagged")
+	}
+}
+
+func TestIdempotentConflicts(t *testing.T) {
+	cases := []struct {
+		name   string
+		key    string
+		token  string
+		body   string
+		status int
+		calls  int
+	}{
+		{"same key with another body", "key", "token", `{"property":"p2"}`, http.StatusConflict, 1},
+		{"same key from another caller", "key", "other", `{"property":"p2"}`, http.StatusCreated, 2},
+		{"another key", "other", "token", `{"property":"p2"}`, http.StatusCreated, 2},
+		{"no key", "", "token", `{"property":"p1"}`, http.StatusCreated, 2},
+	}
+	for _, c := range cases {
+		status, calls := http.StatusCreated, 0
+		e := newIdempotentRoute(&status, &calls)
+		postIdempotent(e, "key", "token", `{"property":"p1"}`)
+
+		if response := postIdempotent(e, c.key, c.token, c.body); response.Code != c.status || calls != c.calls {
+			t.Errorf("%s: expected %d after %d calls, got %d after %d", c.name, c.status, c.calls, response.Code, calls)
+		}
+	}
+}
+
+func TestIdempotentRetriesFailedRequests(t *testing.T) {
+	status, calls := http.StatusNotAcceptable, 0
+	e := newIdempotentRoute(&status, &calls)
+
+	postIdempotent(e, "key", "token", `{"property":"p1"}`)
+	status = http.StatusCreated
+	response := postIdempotent(e, "key", "token", `{"property":"p1"}`)
+
+	if response.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("expected a failed request to be retried, got %d after %d calls", response.Code, calls)
+	}
+}
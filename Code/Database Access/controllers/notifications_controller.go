@@ -18,6 +18,7 @@ import (
 type NotificationsController struct {
 	NotificationsService interfaces.INotificationService
 	ReservationsService  interfaces.IReservationService
+	ReminderService      interfaces.IReminderService
 }
 
 const (
@@ -26,7 +27,7 @@ const (
 	GasReportChannel         = "Gas"
 )
 
-func NewNotificationsController(notificationsService interfaces.INotificationService, reservationsService interfaces.IReservationService) *NotificationsController {
+func NewNotificationsController(notificationsService interfaces.INotificationService, reservationsService interfaces.IReservationService, reminderService interfaces.IReminderService) *NotificationsController {
 	notificationsService.OpenChannel(FireReportChannel)
 	notificationsService.OpenChannel(ElectricityReportChannel)
 	notificationsService.OpenChannel(GasReportChannel)
@@ -38,6 +39,7 @@ func NewNotificationsController(notificationsService interfaces.INotificationSer
 	return &NotificationsController{
 		NotificationsService: notificationsService,
 		ReservationsService:  reservationsService,
+		ReminderService:      reminderService,
 	}
 }
 
@@ -160,3 +162,14 @@ func (c *NotificationsController) SubscribeTenantToPropertyChannel(tenantEmail s
 	logger.Info("Controller: Tenant subscribed to property channel")
 	return nil
 }
+
+func (c *NotificationsController) SendDueReminders() error {
+	logger.Info("Controller: SendDueReminders")
+	err := c.ReminderService.SendDueReminders()
+	if err != nil {
+		logger.Error("Controller: Error in SendDueReminders: ", err)
+	} else {
+		logger.Info("Controller: SendDueReminders done")
+	}
+	return err
+}
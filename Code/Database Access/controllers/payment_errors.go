@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"pocketbase_go/gateways"
+)
+
+// paymentErrorStatus answers 503 while the payment provider is unavailable so
+// clients know they can try again later, 202 when the provider accepted the
+// payment and settles it later, and 406 for any other error.
+func paymentErrorStatus(err error) int {
+	if errors.Is(err, gateways.ErrPaymentPending) {
+		return http.StatusAccepted
+	}
+	if errors.Is(err, gateways.ErrPaymentsUnavailable) || gateways.IsUnavailable(err) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusNotAcceptable
+}
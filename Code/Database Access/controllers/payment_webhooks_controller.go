@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"pocketbase_go/gateways"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	"pocketbase_go/services/interfaces"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// webhookTolerance bounds how old a signed callback can be when it arrives.
+const webhookTolerance = 5 * time.Minute
+
+// PaymentWebhooksController receives the callbacks of the Payment-Module for
+// the charges and refunds it settles in the background.
+type PaymentWebhooksController struct {
+	PaymentService interfaces.IPaymentService
+	secret         string
+}
+
+func NewPaymentWebhooksController(paymentService interfaces.IPaymentService, secret string) *PaymentWebhooksController {
+	return &PaymentWebhooksController{
+		PaymentService: paymentService,
+		secret:         secret,
+	}
+}
+
+func (controller *PaymentWebhooksController) InitPaymentWebhookEndpoints(app core.App) {
+	app.OnBeforeServe().Add(func(e *core.ServeEvent) error {
+		e.Router.POST("/payments/webhook", func(c echo.Context) error {
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"message": "Failed to read request data"})
+			}
+
+			signature := c.Request().Header.Get(gateways.WebhookSignatureHeader)
+			if err := gateways.VerifyWebhookSignature(controller.secret, signature, body, webhookTolerance, time.Now()); err != nil {
+				logger.Error("Controller: Rejected payment webhook: ", err)
+				return c.JSON(http.StatusUnauthorized, map[string]string{"message": err.Error()})
+			}
+
+			var event my_models.PaymentEvent
+			if err := json.Unmarshal(body, &event); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"message": "Failed to read request data"})
+			}
+			if err := event.Validate(); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+			}
+
+			// Any other error makes the Payment-Module deliver the event again
+			if err := controller.PaymentService.HandlePaymentEvent(event); err != nil {
+				logger.Error("Controller: Error in HandlePaymentEvent: ", err)
+				return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, map[string]string{"message": "Success"})
+		})
+
+		return nil
+	})
+}
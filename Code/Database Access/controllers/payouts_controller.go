@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	"pocketbase_go/services/interfaces"
+
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type PayoutsController struct {
+	Service     interfaces.IPayoutsService
+	AuthService interfaces.IAuthService
+}
+
+func (controller *PayoutsController) InitPayoutEndpoints(app core.App) {
+	app.OnBeforeServe().Add(func(e *core.ServeEvent) error {
+		e.Router.GET("/owner/payouts", func(c echo.Context) error {
+			token := c.Request().Header.Get("auth")
+
+			response, err := controller.GetOwnerPayouts(token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, response)
+		})
+
+		return nil
+	})
+}
+
+func (controller *PayoutsController) GetOwnerPayouts(token string) (my_models.OwnerPayouts, error) {
+	roles, userId, err := controller.AuthService.Login(token)
+	if err != nil {
+		logger.Error("Controller: Error in GetOwnerPayouts: ", err)
+		return my_models.OwnerPayouts{}, err
+	}
+
+	for _, role := range roles {
+		if role == "Owner" {
+			return controller.Service.GetOwnerPayouts(userId)
+		}
+	}
+
+	logger.Error("Controller: Error in GetOwnerPayouts: User is not an Owner")
+	return my_models.OwnerPayouts{}, fmt.Errorf("provided token does not belong to an Owner user")
+}
+
+func (controller *PayoutsController) ProcessPayouts() error {
+	logger.Info("Controller: ProcessPayouts")
+	err := controller.Service.ProcessPayouts()
+	if err != nil {
+		logger.Error("Controller: Error in ProcessPayouts: ", err)
+	} else {
+		logger.Info("Controller: ProcessPayouts done")
+	}
+	return err
+}
@@ -17,9 +17,10 @@ import (
 )
 
 type PropertyController struct {
-	Service        interfaces.IPropertyService
-	PaymentService interfaces.IPaymentService
-	AuthService    interfaces.IAuthService
+	Service            interfaces.IPropertyService
+	PaymentService     interfaces.IPaymentService
+	AuthService        interfaces.IAuthService
+	IdempotencyService interfaces.IIdempotencyService
 }
 
 func (controller *PropertyController) InitPropertyEndpoints(app core.App) {
@@ -99,6 +100,125 @@ func (controller *PropertyController) InitPropertyEndpoints(app core.App) {
 			return c.JSON(http.StatusCreated, map[string]string{"message": "Success"})
 		})
 
+		e.Router.PUT("/property/:id/instantBook", func(c echo.Context) error {
+			id := c.PathParam("id")
+			token := c.Request().Header.Get("auth")
+
+			type InstantBookBody struct {
+				InstantBook bool                       `json:"instantBook"`
+				Rules       my_models.InstantBookRules `json:"rules"`
+			}
+
+			var req InstantBookBody
+			if err := c.Bind(&req); err != nil {
+				logger.Error("Failed to read request data", err)
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			err := controller.Service.UpdateInstantBook(id, req.InstantBook, req.Rules, token)
+			if err != nil {
+				logger.Error(err.Error())
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, map[string]string{"message": "Success"})
+		})
+
+		e.Router.PUT("/property/:id/bookingRules", func(c echo.Context) error {
+			id := c.PathParam("id")
+			token := c.Request().Header.Get("auth")
+
+			var req my_models.BookingRules
+			if err := c.Bind(&req); err != nil {
+				logger.Error("Failed to read request data", err)
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			err := controller.Service.UpdateBookingRules(id, req, token)
+			if err != nil {
+				logger.Error(err.Error())
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, map[string]string{"message": "Success"})
+		})
+
+		e.Router.PUT("/property/:id/cancellationPolicy", func(c echo.Context) error {
+			id := c.PathParam("id")
+			token := c.Request().Header.Get("auth")
+
+			var req *my_models.CancellationPolicy
+			if err := c.Bind(&req); err != nil {
+				logger.Error("Failed to read request data", err)
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			err := controller.Service.UpdateCancellationPolicy(id, req, token)
+			if err != nil {
+				logger.Error(err.Error())
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, map[string]string{"message": "Success"})
+		})
+
+		e.Router.PUT("/property/:id/paymentTerms", func(c echo.Context) error {
+			id := c.PathParam("id")
+			token := c.Request().Header.Get("auth")
+
+			var req my_models.PaymentTerms
+			if err := c.Bind(&req); err != nil {
+				logger.Error("Failed to read request data", err)
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			err := controller.Service.UpdatePaymentTerms(id, req, token)
+			if err != nil {
+				logger.Error(err.Error())
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, map[string]string{"message": "Success"})
+		})
+
+		e.Router.GET("/property/:id/priceRules", func(c echo.Context) error {
+			id := c.PathParam("id")
+
+			rules, err := controller.Service.GetPriceRules(id)
+			if err != nil {
+				logger.Error(err.Error())
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, rules)
+		})
+
+		e.Router.POST("/property/:id/priceRules", func(c echo.Context) error {
+			id := c.PathParam("id")
+			token := c.Request().Header.Get("auth")
+
+			var req my_models.PriceRule
+			if err := c.Bind(&req); err != nil {
+				logger.Error("Failed to read request data", err)
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			ruleId, err := controller.Service.AddPriceRule(id, req, token)
+			if err != nil {
+				logger.Error(err.Error())
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusCreated, map[string]string{"message": "Success", "id": ruleId})
+		})
+
+		e.Router.DELETE("/property/:id/priceRules/:ruleId", func(c echo.Context) error {
+			id := c.PathParam("id")
+			ruleId := c.PathParam("ruleId")
+			token := c.Request().Header.Get("auth")
+
+			err := controller.Service.RemovePriceRule(id, ruleId, token)
+			if err != nil {
+				logger.Error(err.Error())
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, map[string]string{"message": "Success"})
+		})
+
 		e.Router.POST("/property", func(c echo.Context) error {
 			token := c.Request().Header.Get("auth")
 
@@ -108,7 +228,7 @@ func (controller *PropertyController) InitPropertyEndpoints(app core.App) {
 				logger.Error("Failed to read request data", err)
 				return apis.NewBadRequestError("Failed to read request data", err)
 			}
-			logger.Info("Request: %v\n", req)
+			logger.Info("Request: ", req)
 			propertyId, err := controller.PostProperty(req, token)
 			if err != nil {
 				logger.Error(err.Error())
@@ -302,7 +422,7 @@ func (controller *PropertyController) InitPropertyEndpoints(app core.App) {
 		e.Router.POST("/property/pay", func(c echo.Context) error {
 			type PayBody struct {
 				PropertyId string `json:"propertyId"`
-				CardInfo   my_models.CardInformation
+				CardToken  string `json:"cardToken"`
 			}
 
 			var req PayBody
@@ -310,12 +430,12 @@ func (controller *PropertyController) InitPropertyEndpoints(app core.App) {
 			if err := c.Bind(&req); err != nil {
 				return apis.NewBadRequestError("Failed to read request data", err)
 			}
-			response := controller.PayProperty(req.PropertyId, req.CardInfo)
+			response := controller.PayProperty(req.PropertyId, req.CardToken)
 			if response != nil {
-				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": response.Error()})
+				return c.JSON(paymentErrorStatus(response), map[string]string{"message": response.Error()})
 			}
 			return c.JSON(http.StatusCreated, map[string]string{"message": "Success"})
-		})
+		}, Idempotent(controller.IdempotencyService))
 
 		return nil
 	})
@@ -370,7 +490,7 @@ func (c *PropertyController) PostProperty(property my_models.Property, userToken
 }
 
 func (c *PropertyController) AddUnavailableDates(propertyId string, dates []my_models.DateRange, userToken string) error {
-	logger.Info("Controller: Adding unavailable dates to property with id: %d", propertyId)
+	logger.Info("Controller: Adding unavailable dates to property with id: ", propertyId)
 	err := c.Service.AddUnavailableDates(propertyId, dates, userToken)
 	if err != nil {
 		return err
@@ -378,9 +498,9 @@ func (c *PropertyController) AddUnavailableDates(propertyId string, dates []my_m
 	return nil
 }
 
-func (c *PropertyController) PayProperty(propertyId string, cardInformation my_models.CardInformation) error {
+func (c *PropertyController) PayProperty(propertyId string, cardToken string) error {
 	logger.Info("Controller: Paying for property with id: ", propertyId)
-	err := c.PaymentService.PayProperty(propertyId, cardInformation)
+	err := c.PaymentService.PayProperty(propertyId, cardToken)
 	if err != nil {
 		return err
 	}
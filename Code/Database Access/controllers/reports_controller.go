@@ -11,6 +11,7 @@ import (
 	pipes_and_filters "pocketbase_go/pipes-and-filters"
 	"pocketbase_go/services/interfaces"
 	"pocketbase_go/workers"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v5"
@@ -112,6 +113,28 @@ func (controller *ReportsController) InitReportsEndpoints(app core.App) {
 			return c.JSON(http.StatusOK, response)
 		})
 
+		e.Router.GET("/reports/host-cancellations", func(c echo.Context) error {
+			token := c.Request().Header.Get("auth")
+
+			fromDate := c.QueryParam("fromDate")
+			untilDate := c.QueryParam("untilDate")
+			minCancellations := 2
+			if minQuery := c.QueryParam("minCancellations"); minQuery != "" {
+				value, err := strconv.Atoi(minQuery)
+				if err != nil {
+					return c.JSON(http.StatusNotAcceptable, map[string]string{"message": "minCancellations must be a number"})
+				}
+				minCancellations = value
+			}
+
+			items, err := controller.GetFrequentHostCancellers(token, fromDate, untilDate, minCancellations)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+
+			return c.JSON(http.StatusOK, map[string]interface{}{"items": items})
+		})
+
 		e.Router.POST("/reports/app", func(c echo.Context) error {
 			var req mongo_models.AppReport
 			if err := c.Bind(&req); err != nil {
@@ -240,6 +263,37 @@ func (c *ReportsController) GetPropertiesIncomes(userToken string, property_id s
 	return my_models.IncomeReport{}, fmt.Errorf("user is not authorized to perform this action")
 }
 
+func (c *ReportsController) GetFrequentHostCancellers(userToken string, fromDateStr string, untilDateStr string, minCancellations int) ([]my_models.HostCancellationsReportItem, error) {
+	logger.Info("Controller: Getting frequent host cancellers")
+	roles, _, err := c.AuthService.Login(userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, role := range roles {
+		if role == "Admin" {
+			fromDate, err := time.Parse(time.DateOnly, fromDateStr)
+			if err != nil {
+				return nil, err
+			}
+
+			untilDate, err := time.Parse(time.DateOnly, untilDateStr)
+			if err != nil {
+				return nil, err
+			}
+
+			if fromDate.After(untilDate) {
+				return nil, fmt.Errorf("from date must be before until date")
+			}
+
+			return c.ReportsService.GetFrequentHostCancellers(fromDate, untilDate, minCancellations)
+		}
+	}
+
+	logger.Error("Controller: User is not authorized to get host cancellations")
+	return nil, fmt.Errorf("user is not authorized to perform this action")
+}
+
 func (c *ReportsController) GetOccupations(userToken string, fromDateStr string, untilDateStr string) ([]my_models.OccupationsReportItem, error) {
 	logger.Info("Controller: Getting occupations")
 	roles, _, err := c.AuthService.Login(userToken)
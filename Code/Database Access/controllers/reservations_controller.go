@@ -3,10 +3,11 @@ package controllers
 import (
 	"fmt"
 	"net/http"
-	"os"
+	"path/filepath"
 	"pocketbase_go/logger"
 	"pocketbase_go/my_models"
 	"pocketbase_go/services/interfaces"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v5"
@@ -19,6 +20,9 @@ type ReservationsController struct {
 	ReservationsService interfaces.IReservationService
 	AuthService         interfaces.IAuthService
 	PaymentService      interfaces.IPaymentService
+	PricingService      interfaces.IPricingService
+	IdempotencyService  interfaces.IIdempotencyService
+	MessagesService     interfaces.IMessagesService
 }
 
 func (controller *ReservationsController) InitReservationEndpoints(app core.App, monitorReservations, monitorReservationPaymentSuccess, monitorReservationPaymentFailure prometheus.Counter) {
@@ -50,12 +54,44 @@ func (controller *ReservationsController) InitReservationEndpoints(app core.App,
 			if tenantLastNameQuery := c.QueryParam("lastName"); tenantLastNameQuery != "" {
 				filter.TenantLastName = &tenantLastNameQuery
 			}
+			if sortQuery := c.QueryParam("sort"); sortQuery != "" {
+				filter.Sort = &sortQuery
+			}
+			if pageQuery := c.QueryParam("page"); pageQuery != "" {
+				page, err := strconv.Atoi(pageQuery)
+				if err != nil {
+					return c.JSON(http.StatusNotAcceptable, map[string]string{"message": "page must be a number"})
+				}
+				filter.Page = &page
+			}
+			if sizeQuery := c.QueryParam("size"); sizeQuery != "" {
+				size, err := strconv.Atoi(sizeQuery)
+				if err != nil {
+					return c.JSON(http.StatusNotAcceptable, map[string]string{"message": "size must be a number"})
+				}
+				filter.Size = &size
+			}
+
+			if format := c.QueryParam("format"); format != "" && format != "json" {
+				file, err := controller.ExportReservations(filter, format, token)
+				if err != nil {
+					return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+				}
+
+				contentType := "text/csv"
+				if format == my_models.XlsxExportFormat {
+					contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+				}
+				fileName := fmt.Sprintf("reservations-%s.%s", time.Now().Format(time.DateOnly), format)
+				c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+				return c.Blob(http.StatusOK, contentType, file)
+			}
 
 			response, err := controller.GetFilteredReservations(filter, token)
 			if err != nil {
 				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
 			}
-			return c.JSON(http.StatusCreated, response)
+			return c.JSON(http.StatusOK, response)
 		})
 
 		e.Router.POST("/reservations", func(c echo.Context) error {
@@ -68,13 +104,53 @@ func (controller *ReservationsController) InitReservationEndpoints(app core.App,
 			if err := c.Bind(&req); err != nil {
 				return apis.NewBadRequestError("Failed to read request data", err)
 			}
-			fmt.Fprintf(os.Stdout, "Request: %v\n", req)
-			response := controller.PostReservation(req, token)
-			if response != nil {
-				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": response.Error()})
+			reservation, err := controller.PostReservation(req, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
 			}
 			monitorReservations.Inc()
-			return c.JSON(http.StatusCreated, map[string]string{"message": "Success"})
+			return c.JSON(http.StatusCreated, map[string]string{"message": "Success", "id": reservation.ID, "status": reservation.Status})
+		}, Idempotent(controller.IdempotencyService))
+
+		e.Router.POST("/reservations/quote", func(c echo.Context) error {
+			token := c.Request().Header.Get("auth")
+
+			var req my_models.QuoteRequest
+			if err := c.Bind(&req); err != nil {
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			response, err := controller.QuoteReservation(req, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, response)
+		})
+
+		e.Router.GET("/me/reservations", func(c echo.Context) error {
+			token := c.Request().Header.Get("auth")
+
+			filter := my_models.TenantReservationFilter{Scope: c.QueryParam("scope")}
+			if pageQuery := c.QueryParam("page"); pageQuery != "" {
+				page, err := strconv.Atoi(pageQuery)
+				if err != nil {
+					return c.JSON(http.StatusNotAcceptable, map[string]string{"message": "page must be a number"})
+				}
+				filter.Page = page
+			}
+			if sizeQuery := c.QueryParam("size"); sizeQuery != "" {
+				size, err := strconv.Atoi(sizeQuery)
+				if err != nil {
+					return c.JSON(http.StatusNotAcceptable, map[string]string{"message": "size must be a number"})
+				}
+				filter.Size = size
+			}
+
+			response, err := controller.GetMyReservations(token, filter)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, response)
 		})
 
 		e.Router.GET("/reservations/:email/:propertyId", func(c echo.Context) error {
@@ -100,6 +176,26 @@ func (controller *ReservationsController) InitReservationEndpoints(app core.App,
 			return c.JSON(http.StatusOK, map[string]string{"message": "Success"})
 		})
 
+		e.Router.POST("/reservations/:reservationId/reject", func(c echo.Context) error {
+			reservationId := c.PathParam("reservationId")
+			token := c.Request().Header.Get("auth")
+
+			type RejectBody struct {
+				Reason string `json:"reason"`
+			}
+
+			var req RejectBody
+			if err := c.Bind(&req); err != nil {
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			err := controller.RejectReservation(reservationId, req.Reason, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, map[string]string{"message": "Success"})
+		})
+
 		e.Router.POST("/reservations/:reservationId/cancel", func(c echo.Context) error {
 			reservationId := c.PathParam("reservationId")
 			email := c.Request().Header.Get("email")
@@ -107,11 +203,32 @@ func (controller *ReservationsController) InitReservationEndpoints(app core.App,
 
 			refundPercentage, err := controller.CancelReservation(email, reservationId, token)
 			if err != nil {
-				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+				return c.JSON(paymentErrorStatus(err), map[string]string{"message": err.Error()})
 			}
 			return c.JSON(http.StatusOK, map[string]string{"message": "Success", "refundPercentage": fmt.Sprintf("%f", refundPercentage)})
 		})
 
+		e.Router.POST("/reservations/:reservationId/hostCancel", func(c echo.Context) error {
+			reservationId := c.PathParam("reservationId")
+			token := c.Request().Header.Get("auth")
+
+			type HostCancelBody struct {
+				Reason     string `json:"reason"`
+				BlockDates bool   `json:"blockDates"`
+			}
+
+			var req HostCancelBody
+			if err := c.Bind(&req); err != nil {
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			refund, err := controller.HostCancelReservation(reservationId, req.Reason, req.BlockDates, token)
+			if err != nil {
+				return c.JSON(paymentErrorStatus(err), map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, map[string]interface{}{"message": "Success", "refund": refund})
+		})
+
 		e.Router.POST("/reservations/:reservationId/remove", func(c echo.Context) error {
 			reservationId := c.PathParam("reservationId")
 			token := c.Request().Header.Get("auth")
@@ -123,6 +240,142 @@ func (controller *ReservationsController) InitReservationEndpoints(app core.App,
 			return c.JSON(http.StatusOK, map[string]string{"message": "Success"})
 		})
 
+		e.Router.GET("/reservations/:reservationId/guests", func(c echo.Context) error {
+			reservationId := c.PathParam("reservationId")
+			token := c.Request().Header.Get("auth")
+
+			response, err := controller.GetGuests(reservationId, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, response)
+		})
+
+		e.Router.GET("/reservations/:reservationId/payments", func(c echo.Context) error {
+			reservationId := c.PathParam("reservationId")
+			token := c.Request().Header.Get("auth")
+
+			response, err := controller.GetPaymentSchedule(reservationId, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, response)
+		})
+
+		e.Router.GET("/reservations/:reservationId/payments/history", func(c echo.Context) error {
+			reservationId := c.PathParam("reservationId")
+			token := c.Request().Header.Get("auth")
+
+			response, err := controller.GetPaymentHistory(reservationId, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, response)
+		})
+
+		e.Router.POST("/reservations/:reservationId/refunds", func(c echo.Context) error {
+			reservationId := c.PathParam("reservationId")
+			token := c.Request().Header.Get("auth")
+
+			type RefundBody struct {
+				Amount float64 `json:"amount"`
+			}
+
+			var req RefundBody
+			if err := c.Bind(&req); err != nil {
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			response, err := controller.RefundReservation(reservationId, req.Amount, token)
+			if err != nil {
+				return c.JSON(paymentErrorStatus(err), map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusCreated, response)
+		})
+
+		e.Router.PUT("/reservations/:reservationId/guests", func(c echo.Context) error {
+			reservationId := c.PathParam("reservationId")
+			token := c.Request().Header.Get("auth")
+
+			type GuestsBody struct {
+				Guests []my_models.Guest `json:"guests"`
+			}
+
+			var req GuestsBody
+			if err := c.Bind(&req); err != nil {
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			response, err := controller.UpdateGuests(reservationId, req.Guests, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, response)
+		})
+
+		e.Router.GET("/reservations/:reservationId/messages", func(c echo.Context) error {
+			reservationId := c.PathParam("reservationId")
+			token := c.Request().Header.Get("auth")
+
+			response, err := controller.GetMessages(reservationId, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, response)
+		})
+
+		e.Router.POST("/reservations/:reservationId/messages", func(c echo.Context) error {
+			reservationId := c.PathParam("reservationId")
+			token := c.Request().Header.Get("auth")
+
+			type MessageBody struct {
+				Body string `json:"body"`
+			}
+
+			// Attachments come as "files" in a multipart form, plain messages can also be sent as JSON
+			var req MessageBody
+			var attachments []my_models.MessageAttachment
+			if form, err := c.MultipartForm(); err == nil {
+				req.Body = c.FormValue("body")
+				for _, file := range form.File["files"] {
+					if file.Size > 500000 {
+						return c.JSON(http.StatusNotAcceptable, map[string]string{"message": "File size is too big"})
+					}
+					fileExtension := filepath.Ext(file.Filename)
+					if fileExtension != ".jpg" && fileExtension != ".jpeg" && fileExtension != ".png" {
+						return c.JSON(http.StatusNotAcceptable, map[string]string{"message": "File format is not supported"})
+					}
+
+					fileData, err := file.Open()
+					if err != nil {
+						logger.Error("Failed to open file", err)
+						return c.JSON(http.StatusNotAcceptable, map[string]string{"message": "Failed to open file"})
+					}
+					defer fileData.Close()
+					attachments = append(attachments, my_models.MessageAttachment{File: fileData, Extension: fileExtension})
+				}
+			} else if err := c.Bind(&req); err != nil {
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+
+			response, err := controller.SendMessage(reservationId, req.Body, attachments, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusCreated, response)
+		})
+
+		e.Router.POST("/reservations/:reservationId/messages/read", func(c echo.Context) error {
+			reservationId := c.PathParam("reservationId")
+			token := c.Request().Header.Get("auth")
+
+			marked, err := controller.MarkMessagesRead(reservationId, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, map[string]int64{"marked": marked})
+		})
+
 		e.Router.POST("/reservations/:reservationId/check_in", func(c echo.Context) error {
 			reservationId := c.PathParam("reservationId")
 			token := c.Request().Header.Get("auth")
@@ -149,7 +402,7 @@ func (controller *ReservationsController) InitReservationEndpoints(app core.App,
 			token := c.Request().Header.Get("auth")
 			type PayBody struct {
 				ReservationId string `json:"reservationId"`
-				CardInfo      my_models.CardInformation
+				CardToken     string `json:"cardToken"`
 			}
 
 			var req PayBody
@@ -157,32 +410,65 @@ func (controller *ReservationsController) InitReservationEndpoints(app core.App,
 			if err := c.Bind(&req); err != nil {
 				return apis.NewBadRequestError("Failed to read request data", err)
 			}
-			fmt.Fprintf(os.Stdout, "Request: %v\n", req)
-			response := controller.PayReservation(req.ReservationId, req.CardInfo, token)
+			response := controller.PayReservation(req.ReservationId, req.CardToken, token)
 			if response != nil {
-				monitorReservationPaymentFailure.Inc()
-				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": response.Error()})
+				status := paymentErrorStatus(response)
+				if status != http.StatusAccepted {
+					monitorReservationPaymentFailure.Inc()
+				}
+				return c.JSON(status, map[string]string{"message": response.Error()})
 			}
 			monitorReservationPaymentSuccess.Inc()
 			return c.JSON(http.StatusCreated, map[string]string{"message": "Success"})
+		}, Idempotent(controller.IdempotencyService))
+
+		e.Router.POST("/reservations/pay/async", func(c echo.Context) error {
+			token := c.Request().Header.Get("auth")
+			type PayBody struct {
+				ReservationId string `json:"reservationId"`
+				CardToken     string `json:"cardToken"`
+			}
+
+			var req PayBody
+
+			if err := c.Bind(&req); err != nil {
+				return apis.NewBadRequestError("Failed to read request data", err)
+			}
+			payment, err := controller.StartReservationPayment(req.ReservationId, req.CardToken, token)
+			if err != nil {
+				monitorReservationPaymentFailure.Inc()
+				return c.JSON(paymentErrorStatus(err), map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusAccepted, map[string]string{"paymentId": payment.Id, "status": payment.Status})
+		}, Idempotent(controller.IdempotencyService))
+
+		e.Router.GET("/payments/:paymentId", func(c echo.Context) error {
+			paymentId := c.PathParam("paymentId")
+			token := c.Request().Header.Get("auth")
+
+			response, err := controller.GetPayment(paymentId, token)
+			if err != nil {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{"message": err.Error()})
+			}
+			return c.JSON(http.StatusOK, response)
 		})
 
 		return nil
 	})
 }
 
-func (c *ReservationsController) GetFilteredReservations(filter my_models.ReservationFilter, userToken string) ([]my_models.ReservationModel, error) {
+func (c *ReservationsController) GetFilteredReservations(filter my_models.ReservationFilter, userToken string) (my_models.ReservationsPage, error) {
 	roles, _, err := c.AuthService.Login(userToken)
 	if err != nil {
 		logger.Error("Controller: Error in GetFilteredReservations: ", err)
-		return nil, err
+		return my_models.ReservationsPage{}, err
 	}
 
 	for _, role := range roles {
 		if role == "Admin" || role == "Operator" {
 			reservations, err := c.ReservationsService.GetFilteredReservations(filter)
 			if err != nil {
-				return nil, err
+				return my_models.ReservationsPage{}, err
 			}
 
 			return reservations, nil
@@ -190,14 +476,31 @@ func (c *ReservationsController) GetFilteredReservations(filter my_models.Reserv
 	}
 
 	logger.Error("Controller: Error in GetFilteredReservations: provided token does not belong to an Admin or Operator user")
+	return my_models.ReservationsPage{}, fmt.Errorf("provided token does not belong to an Admin or Operator user")
+}
+
+func (c *ReservationsController) ExportReservations(filter my_models.ReservationFilter, format string, userToken string) ([]byte, error) {
+	roles, _, err := c.AuthService.Login(userToken)
+	if err != nil {
+		logger.Error("Controller: Error in ExportReservations: ", err)
+		return nil, err
+	}
+
+	for _, role := range roles {
+		if role == "Admin" || role == "Operator" {
+			return c.ReservationsService.ExportReservations(filter, format)
+		}
+	}
+
+	logger.Error("Controller: Error in ExportReservations: provided token does not belong to an Admin or Operator user")
 	return nil, fmt.Errorf("provided token does not belong to an Admin or Operator user")
 }
 
-func (c *ReservationsController) PostReservation(reservation my_models.ReservationModel, userToken string) error {
+func (c *ReservationsController) PostReservation(reservation my_models.ReservationModel, userToken string) (my_models.ReservationModel, error) {
 	roles, _, err := c.AuthService.Login(userToken)
 	if err != nil {
 		logger.Error("Controller: Error in PostReservation: ", err)
-		return err
+		return my_models.ReservationModel{}, err
 	}
 
 	for _, role := range roles {
@@ -207,7 +510,35 @@ func (c *ReservationsController) PostReservation(reservation my_models.Reservati
 	}
 
 	logger.Error("Controller: Error in PostReservation: provided token does not belong to a Tenant user")
-	return fmt.Errorf("provided token does not belong to a Client user")
+	return my_models.ReservationModel{}, fmt.Errorf("provided token does not belong to a Client user")
+}
+
+func (c *ReservationsController) QuoteReservation(request my_models.QuoteRequest, userToken string) (my_models.PriceQuote, error) {
+	_, _, err := c.AuthService.Login(userToken)
+	if err != nil {
+		logger.Error("Controller: Error in QuoteReservation: ", err)
+		return my_models.PriceQuote{}, err
+	}
+
+	if request.PropertyId == "" {
+		return my_models.PriceQuote{}, fmt.Errorf("property must be provided")
+	}
+
+	fromDate, err := time.Parse(time.DateOnly, request.ReservedFrom)
+	if err != nil {
+		return my_models.PriceQuote{}, fmt.Errorf("invalid reserved_from format, expected YYYY-MM-DD")
+	}
+	untilDate, err := time.Parse(time.DateOnly, request.ReservedUntil)
+	if err != nil {
+		return my_models.PriceQuote{}, fmt.Errorf("invalid reserved_until format, expected YYYY-MM-DD")
+	}
+
+	quote, err := c.PricingService.Quote(request.PropertyId, fromDate, untilDate, request.Country)
+	if err != nil {
+		return my_models.PriceQuote{}, err
+	}
+
+	return quote, quote.CheckMinNights()
 }
 
 func (c *ReservationsController) GetOwnReservation(token string, email string, propertyId string) (my_models.ReservationModel, error) {
@@ -239,21 +570,71 @@ func (c *ReservationsController) GetOwnReservation(token string, email string, p
 	return my_models.ReservationModel{}, err
 }
 
+func (c *ReservationsController) GetMyReservations(token string, filter my_models.TenantReservationFilter) (my_models.TenantReservationsPage, error) {
+	roles, userId, err := c.AuthService.Login(token)
+	if err != nil {
+		logger.Error("Controller: Error in GetMyReservations: ", err)
+		return my_models.TenantReservationsPage{}, err
+	}
+
+	for _, role := range roles {
+		if role == "Tenant" {
+			user, err := c.AuthService.GetUserById(userId)
+			if err != nil {
+				logger.Error("Controller: Error in GetMyReservations: ", err)
+				return my_models.TenantReservationsPage{}, err
+			}
+			return c.ReservationsService.GetTenantReservations(user.Email, filter)
+		}
+	}
+
+	logger.Error("Controller: Error in GetMyReservations: User is not a Tenant")
+	return my_models.TenantReservationsPage{}, fmt.Errorf("provided token does not belong to a Tenant user")
+}
+
 func (c *ReservationsController) ApproveReservation(reservationId string, userToken string) error {
-	roles, _, err := c.AuthService.Login(userToken)
+	roles, userId, err := c.AuthService.Login(userToken)
+	if err != nil {
+		return err
+	}
+
+	if err := c.authorizeAdminOrPropertyOwner(roles, userId, reservationId); err != nil {
+		logger.Error("Controller: Error in ApproveReservation: ", err)
+		return err
+	}
+
+	return c.ReservationsService.ApproveReservation(reservationId)
+}
+
+func (c *ReservationsController) RejectReservation(reservationId string, reason string, userToken string) error {
+	roles, userId, err := c.AuthService.Login(userToken)
 	if err != nil {
 		return err
 	}
 
+	if err := c.authorizeAdminOrPropertyOwner(roles, userId, reservationId); err != nil {
+		logger.Error("Controller: Error in RejectReservation: ", err)
+		return err
+	}
+
+	return c.ReservationsService.RejectReservation(reservationId, reason)
+}
+
+// authorizeAdminOrPropertyOwner lets Admins through and checks that Owners own the reserved property.
+func (c *ReservationsController) authorizeAdminOrPropertyOwner(roles []string, userId string, reservationId string) error {
 	for _, role := range roles {
 		if role == "Admin" {
-			return c.ReservationsService.ApproveReservation(reservationId)
+			return nil
 		}
 	}
 
-	err = fmt.Errorf("provided token does not belong to an Admin")
-	logger.Error("Controller: Error in ApproveReservation: ", err)
-	return err
+	for _, role := range roles {
+		if role == "Owner" {
+			return c.ReservationsService.ValidatePropertyOwner(reservationId, userId)
+		}
+	}
+
+	return fmt.Errorf("provided token does not belong to an Admin or the property Owner")
 }
 
 func (c *ReservationsController) CancelReservation(email string, reservationId string, userToken string) (refundPercentage float64, err error) {
@@ -273,6 +654,28 @@ func (c *ReservationsController) CancelReservation(email string, reservationId s
 	return 0, err
 }
 
+func (c *ReservationsController) HostCancelReservation(reservationId string, reason string, blockDates bool, userToken string) (float64, error) {
+	roles, userId, err := c.AuthService.Login(userToken)
+	if err != nil {
+		logger.Error("Controller: Error in HostCancelReservation: ", err)
+		return 0, err
+	}
+
+	if err := c.authorizeAdminOrPropertyOwner(roles, userId, reservationId); err != nil {
+		logger.Error("Controller: Error in HostCancelReservation: ", err)
+		return 0, err
+	}
+
+	cancelledBy := "Owner"
+	for _, role := range roles {
+		if role == "Admin" {
+			cancelledBy = "Admin"
+		}
+	}
+
+	return c.ReservationsService.HostCancelReservation(reservationId, reason, cancelledBy, blockDates)
+}
+
 func (c *ReservationsController) RemoveReservation(reservationId string, userToken string) (err error) {
 	roles, _, err := c.AuthService.Login(userToken)
 	if err != nil {
@@ -290,6 +693,274 @@ func (c *ReservationsController) RemoveReservation(reservationId string, userTok
 	return err
 }
 
+// GetGuests lets the tenant of the reservation, the property Owner and Admins see the guest roster.
+func (c *ReservationsController) GetGuests(reservationId string, token string) ([]my_models.Guest, error) {
+	roles, userId, err := c.AuthService.Login(token)
+	if err != nil {
+		logger.Error("Controller: Error in GetGuests: ", err)
+		return nil, err
+	}
+
+	if err := c.authorizeReservationParticipant(roles, userId, reservationId); err != nil {
+		logger.Error("Controller: Error in GetGuests: ", err)
+		return nil, err
+	}
+
+	return c.ReservationsService.GetGuests(reservationId)
+}
+
+func (c *ReservationsController) GetPaymentSchedule(reservationId string, token string) ([]my_models.PaymentInstallment, error) {
+	roles, userId, err := c.AuthService.Login(token)
+	if err != nil {
+		logger.Error("Controller: Error in GetPaymentSchedule: ", err)
+		return nil, err
+	}
+
+	if err := c.authorizeReservationParticipant(roles, userId, reservationId); err != nil {
+		logger.Error("Controller: Error in GetPaymentSchedule: ", err)
+		return nil, err
+	}
+
+	return c.PaymentService.GetPaymentSchedule(reservationId)
+}
+
+// GetPaymentHistory lets the tenant of the reservation and Admins see every
+// charge and refund attempt made for it.
+func (c *ReservationsController) GetPaymentHistory(reservationId string, token string) ([]my_models.Payment, error) {
+	if err := c.authorizeTenantOrAdmin(reservationId, token); err != nil {
+		logger.Error("Controller: Error in GetPaymentHistory: ", err)
+		return nil, err
+	}
+
+	return c.PaymentService.GetPaymentHistory(reservationId)
+}
+
+// GetPayment lets clients poll the status of a payment made with the async mode.
+func (c *ReservationsController) GetPayment(paymentId string, token string) (my_models.Payment, error) {
+	payment, err := c.PaymentService.GetPayment(paymentId)
+	if err != nil {
+		logger.Error("Controller: Error in GetPayment: ", err)
+		return my_models.Payment{}, err
+	}
+
+	// Properties are paid by their owner, without a reservation
+	if payment.ReservationId == "" {
+		err = c.authorizePropertyOwnerOrAdmin(payment.PropertyId, token)
+	} else {
+		err = c.authorizeTenantOrAdmin(payment.ReservationId, token)
+	}
+	if err != nil {
+		logger.Error("Controller: Error in GetPayment: ", err)
+		return my_models.Payment{}, err
+	}
+
+	return payment, nil
+}
+
+// authorizeTenantOrAdmin checks that the token belongs to an Admin or to the
+// tenant that booked the reservation.
+func (c *ReservationsController) authorizeTenantOrAdmin(reservationId string, token string) error {
+	roles, userId, err := c.AuthService.Login(token)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if role == "Admin" {
+			return nil
+		}
+	}
+
+	for _, role := range roles {
+		if role == "Tenant" {
+			return c.authorizeReservationTenant(userId, reservationId)
+		}
+	}
+
+	return fmt.Errorf("provided token does not belong to an Admin or the tenant of the reservation")
+}
+
+// authorizeReservationTenant checks that the user is the tenant that booked the reservation.
+func (c *ReservationsController) authorizeReservationTenant(userId string, reservationId string) error {
+	user, err := c.AuthService.GetUserById(userId)
+	if err != nil {
+		return err
+	}
+	reservation, err := c.ReservationsService.GetReservationById(reservationId)
+	if err != nil {
+		return err
+	}
+	if reservation.Email != user.Email {
+		return fmt.Errorf("provided token does not belong to the tenant of the reservation")
+	}
+	return nil
+}
+
+// authorizePropertyOwnerOrAdmin checks that the token belongs to an Admin or to
+// the owner of the property.
+func (c *ReservationsController) authorizePropertyOwnerOrAdmin(propertyId string, token string) error {
+	roles, userId, err := c.AuthService.Login(token)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if role == "Admin" {
+			return nil
+		}
+	}
+
+	for _, role := range roles {
+		if role == "Owner" {
+			return c.PaymentService.ValidatePropertyOwner(propertyId, userId)
+		}
+	}
+
+	return fmt.Errorf("provided token does not belong to an Admin or the property Owner")
+}
+
+// RefundReservation lets Admins give back part of what the tenant paid.
+func (c *ReservationsController) RefundReservation(reservationId string, amount float64, token string) ([]my_models.Payment, error) {
+	roles, _, err := c.AuthService.Login(token)
+	if err != nil {
+		logger.Error("Controller: Error in RefundReservation: ", err)
+		return nil, err
+	}
+
+	for _, role := range roles {
+		if role == "Admin" {
+			return c.ReservationsService.RefundReservation(reservationId, amount)
+		}
+	}
+
+	logger.Error("Controller: Error in RefundReservation: User is not an Admin")
+	return nil, fmt.Errorf("provided token does not belong to an Admin user")
+}
+
+// authorizeReservationParticipant lets through the tenant of the reservation,
+// the property Owner and Admins.
+func (c *ReservationsController) authorizeReservationParticipant(roles []string, userId string, reservationId string) error {
+	for _, role := range roles {
+		if role == "Tenant" {
+			user, err := c.AuthService.GetUserById(userId)
+			if err != nil {
+				return err
+			}
+			reservation, err := c.ReservationsService.GetReservationById(reservationId)
+			if err != nil {
+				return err
+			}
+			if reservation.Email == user.Email {
+				return nil
+			}
+		}
+	}
+
+	return c.authorizeAdminOrPropertyOwner(roles, userId, reservationId)
+}
+
+// GetMessages lets both parties of the reservation and Admins read the conversation.
+func (c *ReservationsController) GetMessages(reservationId string, token string) ([]my_models.Message, error) {
+	roles, userId, err := c.AuthService.Login(token)
+	if err != nil {
+		logger.Error("Controller: Error in GetMessages: ", err)
+		return nil, err
+	}
+
+	if err := c.authorizeReservationParticipant(roles, userId, reservationId); err != nil {
+		logger.Error("Controller: Error in GetMessages: ", err)
+		return nil, err
+	}
+
+	return c.MessagesService.GetMessages(reservationId)
+}
+
+func (c *ReservationsController) SendMessage(reservationId string, body string, attachments []my_models.MessageAttachment, token string) (my_models.Message, error) {
+	roles, userId, err := c.AuthService.Login(token)
+	if err != nil {
+		logger.Error("Controller: Error in SendMessage: ", err)
+		return my_models.Message{}, err
+	}
+
+	senderRole, err := c.conversationRole(roles, userId, reservationId)
+	if err != nil {
+		logger.Error("Controller: Error in SendMessage: ", err)
+		return my_models.Message{}, err
+	}
+
+	message := my_models.Message{ReservationId: reservationId, SenderId: userId, SenderRole: senderRole, Body: body}
+	return c.MessagesService.SendMessage(message, attachments)
+}
+
+func (c *ReservationsController) MarkMessagesRead(reservationId string, token string) (int64, error) {
+	roles, userId, err := c.AuthService.Login(token)
+	if err != nil {
+		logger.Error("Controller: Error in MarkMessagesRead: ", err)
+		return 0, err
+	}
+
+	readerRole, err := c.conversationRole(roles, userId, reservationId)
+	if err != nil {
+		logger.Error("Controller: Error in MarkMessagesRead: ", err)
+		return 0, err
+	}
+
+	return c.MessagesService.MarkMessagesRead(reservationId, readerRole)
+}
+
+// conversationRole tells whether the user takes part in the conversation of the
+// reservation as its tenant or as the property Owner.
+func (c *ReservationsController) conversationRole(roles []string, userId string, reservationId string) (string, error) {
+	for _, role := range roles {
+		if role == "Tenant" {
+			user, err := c.AuthService.GetUserById(userId)
+			if err != nil {
+				return "", err
+			}
+			reservation, err := c.ReservationsService.GetReservationById(reservationId)
+			if err != nil {
+				return "", err
+			}
+			if reservation.Email == user.Email {
+				return my_models.TenantSender, nil
+			}
+		}
+	}
+
+	for _, role := range roles {
+		if role == "Owner" {
+			if err := c.ReservationsService.ValidatePropertyOwner(reservationId, userId); err != nil {
+				return "", err
+			}
+			return my_models.OwnerSender, nil
+		}
+	}
+
+	return "", fmt.Errorf("provided token does not belong to the tenant or the property Owner of the reservation")
+}
+
+func (c *ReservationsController) UpdateGuests(reservationId string, guests []my_models.Guest, token string) ([]my_models.Guest, error) {
+	roles, userId, err := c.AuthService.Login(token)
+	if err != nil {
+		logger.Error("Controller: Error in UpdateGuests: ", err)
+		return nil, err
+	}
+
+	for _, role := range roles {
+		if role == "Tenant" {
+			user, err := c.AuthService.GetUserById(userId)
+			if err != nil {
+				logger.Error("Controller: Error in UpdateGuests: ", err)
+				return nil, err
+			}
+			return c.ReservationsService.UpdateGuests(user.Email, reservationId, guests)
+		}
+	}
+
+	logger.Error("Controller: Error in UpdateGuests: User is not a Tenant")
+	return nil, fmt.Errorf("provided token does not belong to a Tenant user")
+}
+
 func (c *ReservationsController) DoCheckIn(reservationId string, token string) error {
 	_, userId, err := c.AuthService.Login(token)
 	if err != nil {
@@ -327,7 +998,7 @@ func (c *ReservationsController) DoCheckIn(reservationId string, token string) e
 		return err
 	}
 
-	if reservation.Status != "Approved" {
+	if reservation.Status != "Approved" && reservation.Status != "PartiallyPaid" && reservation.Status != "Paid" {
 		return fmt.Errorf("reservation is not approved")
 	}
 
@@ -389,7 +1060,7 @@ func (c *ReservationsController) DoCheckOut(reservationId string, token string)
 	return c.ReservationsService.DoCheckOut(reservationId)
 }
 
-func (c *ReservationsController) PayReservation(reservationId string, cardInformation my_models.CardInformation, token string) error {
+func (c *ReservationsController) PayReservation(reservationId string, cardToken string, token string) error {
 	logger.Info("Controller: Paying reservation with id: ", reservationId)
 
 	roles, _, err := c.AuthService.Login(token)
@@ -399,7 +1070,7 @@ func (c *ReservationsController) PayReservation(reservationId string, cardInform
 
 	for _, role := range roles {
 		if role == "Tenant" {
-			return c.PaymentService.PayReservation(reservationId, cardInformation)
+			return c.PaymentService.PayReservation(reservationId, cardToken)
 		}
 	}
 
@@ -408,6 +1079,40 @@ func (c *ReservationsController) PayReservation(reservationId string, cardInform
 	return err
 }
 
+func (c *ReservationsController) StartReservationPayment(reservationId string, cardToken string, token string) (my_models.Payment, error) {
+	logger.Info("Controller: Queueing payment of reservation with id: ", reservationId)
+
+	roles, userId, err := c.AuthService.Login(token)
+	if err != nil {
+		return my_models.Payment{}, err
+	}
+
+	for _, role := range roles {
+		if role == "Tenant" {
+			if err := c.authorizeReservationTenant(userId, reservationId); err != nil {
+				logger.Error("Controller: Error in StartReservationPayment: ", err)
+				return my_models.Payment{}, err
+			}
+			return c.PaymentService.StartReservationPayment(reservationId, cardToken)
+		}
+	}
+
+	err = fmt.Errorf("provided token does not belong to a Tenant user")
+	logger.Error("Controller: Error in StartReservationPayment: ", err)
+	return my_models.Payment{}, err
+}
+
+func (c *ReservationsController) ProcessPaymentJob(body []byte) error {
+	logger.Info("Controller: ProcessPaymentJob")
+	err := c.PaymentService.ProcessPaymentJob(body)
+	if err != nil {
+		logger.Error("Controller: Error in ProcessPaymentJob: ", err)
+	} else {
+		logger.Info("Controller: ProcessPaymentJob done")
+	}
+	return err
+}
+
 func (c *ReservationsController) AutoCancelReservations() error {
 	logger.Info("Controller: AutoCancelReservations")
 	err := c.ReservationsService.AutoCancelReservations()
@@ -418,3 +1123,47 @@ func (c *ReservationsController) AutoCancelReservations() error {
 	}
 	return err
 }
+
+func (c *ReservationsController) ExpirePendingReservations() error {
+	logger.Info("Controller: ExpirePendingReservations")
+	err := c.ReservationsService.ExpirePendingReservations()
+	if err != nil {
+		logger.Error("Controller: Error in ExpirePendingReservations: ", err)
+	} else {
+		logger.Info("Controller: ExpirePendingReservations done")
+	}
+	return err
+}
+
+func (c *ReservationsController) ProcessNoShows() error {
+	logger.Info("Controller: ProcessNoShows")
+	err := c.ReservationsService.ProcessNoShows()
+	if err != nil {
+		logger.Error("Controller: Error in ProcessNoShows: ", err)
+	} else {
+		logger.Info("Controller: ProcessNoShows done")
+	}
+	return err
+}
+
+func (c *ReservationsController) AutoCheckOutReservations() error {
+	logger.Info("Controller: AutoCheckOutReservations")
+	err := c.ReservationsService.AutoCheckOutReservations()
+	if err != nil {
+		logger.Error("Controller: Error in AutoCheckOutReservations: ", err)
+	} else {
+		logger.Info("Controller: AutoCheckOutReservations done")
+	}
+	return err
+}
+
+func (c *ReservationsController) ChargeDueBalances() error {
+	logger.Info("Controller: ChargeDueBalances")
+	err := c.PaymentService.ChargeDueBalances()
+	if err != nil {
+		logger.Error("Controller: Error in ChargeDueBalances: ", err)
+	} else {
+		logger.Info("Controller: ChargeDueBalances done")
+	}
+	return err
+}
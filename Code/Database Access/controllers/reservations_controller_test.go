@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"fmt"
+	"pocketbase_go/my_models"
+	"pocketbase_go/services/interfaces"
+	"pocketbase_go/services/mocks"
+	"testing"
+)
+
+type stubReservationsService struct {
+	interfaces.IReservationService
+}
+
+func (s stubReservationsService) GetReservationById(reservationId string) (my_models.ReservationModel, error) {
+	return my_models.ReservationModel{ID: reservationId, Email: "tenant@mail.com"}, nil
+}
+
+type stubPaymentService struct {
+	interfaces.IPaymentService
+	payment my_models.Payment
+	started bool
+}
+
+func (s *stubPaymentService) GetPayment(paymentId string) (my_models.Payment, error) {
+	return s.payment, nil
+}
+
+func (s *stubPaymentService) StartReservationPayment(reservationId string, cardToken string) (my_models.Payment, error) {
+	s.started = true
+	return my_models.Payment{ReservationId: reservationId}, nil
+}
+
+func (s *stubPaymentService) ValidatePropertyOwner(propertyId string, userId string) error {
+	if userId != "owner" {
+		return fmt.Errorf("user is not the owner of the paid property")
+	}
+	return nil
+}
+
+// testUsers logs every token in as the user of the same id, with the roles below.
+var testUsers = mocks.MockAuthService{
+	LoginFunc: func(token string) ([]string, string, error) {
+		roles := map[string][]string{
+			"tenant":       {"Tenant"},
+			"other_tenant": {"Tenant"},
+			"owner":        {"Owner"},
+			"other_owner":  {"Owner"},
+			"admin":        {"Admin"},
+		}
+		return roles[token], token, nil
+	},
+	GetUserByIdFunc: func(id string) (my_models.User, error) {
+		return my_models.User{Email: id + "@mail.com"}, nil
+	},
+}
+
+func TestGetPaymentAuthorization(t *testing.T) {
+	reservationPayment := my_models.Payment{Id: "p1", ReservationId: "r1"}
+	propertyPayment := my_models.Payment{Id: "p2", PropertyId: "property"}
+
+	cases := []struct {
+		name    string
+		payment my_models.Payment
+		token   string
+		allowed bool
+	}{
+		{"tenant of the reservation", reservationPayment, "tenant", true},
+		{"another tenant", reservationPayment, "other_tenant", false},
+		{"admin", reservationPayment, "admin", true},
+		{"owner of the paid property", propertyPayment, "owner", true},
+		{"another owner", propertyPayment, "other_owner", false},
+		{"tenant on a property payment", propertyPayment, "tenant", false},
+		{"admin on a property payment", propertyPayment, "admin", true},
+	}
+	for _, c := range cases {
+		controller := ReservationsController{
+			ReservationsService: stubReservationsService{},
+			AuthService:         testUsers,
+			PaymentService:      &stubPaymentService{payment: c.payment},
+		}
+
+		_, err := controller.GetPayment(c.payment.Id, c.token)
+		if (err == nil) != c.allowed {
+			t.Errorf("%s: expected allowed %v, got %v", c.name, c.allowed, err)
+		}
+	}
+}
+
+func TestStartReservationPaymentAuthorization(t *testing.T) {
+	cases := []struct {
+		name    string
+		token   string
+		allowed bool
+	}{
+		{"tenant of the reservation", "tenant", true},
+		{"another tenant", "other_tenant", false},
+		{"owner", "owner", false},
+	}
+	for _, c := range cases {
+		payments := &stubPaymentService{}
+		controller := ReservationsController{
+			ReservationsService: stubReservationsService{},
+			AuthService:         testUsers,
+			PaymentService:      payments,
+		}
+
+		_, err := controller.StartReservationPayment("r1", "tok_test", c.token)
+		if (err == nil) != c.allowed || payments.started != c.allowed {
+			t.Errorf("%s: expected allowed %v, got %v", c.name, c.allowed, err)
+		}
+	}
+}
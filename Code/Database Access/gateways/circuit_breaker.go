@@ -0,0 +1,141 @@
+package gateways
+
+import (
+	"errors"
+	"pocketbase_go/logger"
+	"sync"
+	"time"
+)
+
+var ErrPaymentsUnavailable = errors.New("payments temporarily unavailable")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+// CircuitBreaker stops calling the payment provider after failureThreshold
+// consecutive outages. Once openTimeout passes a single trial call is let
+// through: if it works the breaker closes again, otherwise it stays open.
+// Rejections such as declined cards are answers from the provider and do not
+// count as failures.
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	state            BreakerState
+	failures         int
+	openedAt         time.Time
+	trialInFlight    bool
+	failureThreshold int
+	openTimeout      time.Duration
+	now              func() time.Time
+	OnStateChange    func(state BreakerState)
+}
+
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		now:              time.Now,
+	}
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// Execute runs the operation unless the breaker is open, in which case it
+// fails right away with ErrPaymentsUnavailable.
+func (b *CircuitBreaker) Execute(operation func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+
+	err := operation()
+	b.record(IsUnavailable(err))
+	return err
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return ErrPaymentsUnavailable
+		}
+		b.setState(BreakerHalfOpen)
+		b.trialInFlight = true
+	case BreakerHalfOpen:
+		if b.trialInFlight {
+			return ErrPaymentsUnavailable
+		}
+		b.trialInFlight = true
+	}
+
+	return nil
+}
+
+func (b *CircuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.trialInFlight = false
+		if failed {
+			b.open()
+		} else {
+			b.failures = 0
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerClosed && b.failures >= b.failureThreshold {
+		b.open()
+	}
+}
+
+func (b *CircuitBreaker) open() {
+	b.openedAt = b.now()
+	b.setState(BreakerOpen)
+}
+
+func (b *CircuitBreaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+
+	logger.Warn("Gateway: Payment circuit breaker ", b.state, " -> ", state)
+	b.state = state
+	if b.OnStateChange != nil {
+		b.OnStateChange(state)
+	}
+}
@@ -0,0 +1,121 @@
+package gateways
+
+import (
+	"errors"
+	"os"
+	"pocketbase_go/logger"
+	"testing"
+	"time"
+)
+
+var errOutage = &UnavailableError{Err: errors.New("connection refused")}
+
+func TestMain(m *testing.M) {
+	// The logger writes to ./log, keep it out of the source tree
+	dir, err := os.MkdirTemp("", "gateways")
+	if err == nil && os.Chdir(dir) == nil {
+		logger.Initialize("gateways_test.log")
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	failing := func() error { return errOutage }
+	breaker.Execute(failing)
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("expected closed breaker after one failure, got %s", breaker.State())
+	}
+	breaker.Execute(failing)
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("expected open breaker, got %s", breaker.State())
+	}
+
+	called := false
+	err := breaker.Execute(func() error { called = true; return nil })
+	if !errors.Is(err, ErrPaymentsUnavailable) || called {
+		t.Fatalf("expected open breaker to reject the call, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := breaker.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("expected trial call to go through, got %v", err)
+	}
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("expected breaker to close after a successful trial, got %s", breaker.State())
+	}
+}
+
+func TestCircuitBreakerIgnoresDeclines(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.Execute(func() error { return ErrCardDeclined })
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("expected declines not to open the breaker, got %s", breaker.State())
+	}
+}
+
+func TestResilientGatewayRetriesOnlyUnsentCalls(t *testing.T) {
+	attempts := 0
+	gateway := &ResilientPaymentGateway{Gateway: &scriptedGateway{charge: func() error {
+		attempts++
+		if attempts < 3 {
+			return &UnavailableError{Err: errors.New("connection refused"), Sent: false}
+		}
+		return nil
+	}}, Breaker: NewCircuitBreaker(5, time.Minute), sleep: func(time.Duration) {}}
+	gateway.SetConfigValues(3, time.Millisecond, 4*time.Millisecond)
+
+	if _, err := gateway.Charge(testCard, 10); err != nil || attempts != 3 {
+		t.Fatalf("expected charge to succeed on the third attempt, got %v after %d", err, attempts)
+	}
+
+	attempts = 0
+	gateway.Gateway = &scriptedGateway{charge: func() error {
+		attempts++
+		return &UnavailableError{Err: errors.New("timeout"), Sent: true}
+	}}
+	if _, err := gateway.Charge(testCard, 10); err == nil || attempts != 1 {
+		t.Fatalf("expected a charge that may have reached the provider not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestResilientGatewayKeepsPendingReference(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	gateway := &ResilientPaymentGateway{Gateway: &scriptedGateway{charge: func() error {
+		return ErrPaymentPending
+	}}, Breaker: breaker, sleep: func(time.Duration) {}}
+	gateway.SetConfigValues(3, time.Millisecond, 4*time.Millisecond)
+
+	reference, err := gateway.Charge(testCard, 10)
+	if !errors.Is(err, ErrPaymentPending) || reference != "ch" {
+		t.Fatalf("expected pending charge with its reference, got %q %v", reference, err)
+	}
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("expected pending charges not to open the breaker, got %s", breaker.State())
+	}
+}
+
+type scriptedGateway struct {
+	charge func() error
+}
+
+func (g *scriptedGateway) Charge(cardToken string, amount float64) (string, error) {
+	return "ch", g.charge()
+}
+
+func (g *scriptedGateway) Authorize(cardToken string, amount float64) (string, error) {
+	return "auth", nil
+}
+
+func (g *scriptedGateway) Capture(authorizationId string, amount float64) (string, error) {
+	return "ch", nil
+}
+
+func (g *scriptedGateway) Refund(chargeId string, amount float64) (string, error) {
+	return "re", nil
+}
@@ -0,0 +1,47 @@
+package gateways
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCardDeclined means the provider refused to take money from the card.
+var ErrCardDeclined = errors.New("card declined")
+
+// ErrPaymentPending is returned along with the reference of an operation the
+// provider accepted but will settle later, reporting the outcome through a
+// webhook.
+var ErrPaymentPending = errors.New("payment is being processed")
+
+// ErrPaymentOutcomeUnknown means the provider accepted the operation but did not
+// say which reference it got, so whether it went through must be reconciled with
+// the provider. It is a pending payment, nothing may be charged again meanwhile.
+var ErrPaymentOutcomeUnknown = fmt.Errorf("%w, the provider did not return its reference and it must be reconciled", ErrPaymentPending)
+
+// UnavailableError means the provider could not process the call because it was
+// down, timed out or failed on its side. Sent tells whether the request may
+// have reached it, so whether repeating it could charge twice.
+type UnavailableError struct {
+	Err  error
+	Sent bool
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+func IsUnavailable(err error) bool {
+	var unavailable *UnavailableError
+	return errors.As(err, &unavailable)
+}
+
+// transportError wraps an error of the HTTP client. The provider can only act on
+// a request it received in full, so one that was never completely written, as
+// when connecting or the TLS handshake failed, was not sent.
+func transportError(err error, written bool) *UnavailableError {
+	return &UnavailableError{Err: err, Sent: written}
+}
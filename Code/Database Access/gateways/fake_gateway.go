@@ -0,0 +1,157 @@
+package gateways
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	FakeCharge    = "charge"
+	FakeAuthorize = "authorize"
+	FakeCapture   = "capture"
+	FakeRefund    = "refund"
+)
+
+// FakeOperation is a call the fake gateway accepted.
+type FakeOperation struct {
+	Kind      string
+	Reference string
+	CardToken string
+	Amount    float64
+}
+
+type fakeAuthorization struct {
+	cardToken string
+	amount    float64
+	captured  bool
+}
+
+// FakePaymentGateway is a deterministic in-memory gateway for tests. Every card
+// succeeds unless it was scripted to fail with FailCard, references are
+// sequential and refunds can never exceed what was collected.
+type FakePaymentGateway struct {
+	mu             sync.Mutex
+	sequence       int
+	failures       map[string]error
+	authorizations map[string]*fakeAuthorization
+	collected      map[string]float64
+	refunded       map[string]float64
+	operations     []FakeOperation
+}
+
+func NewFakePaymentGateway() *FakePaymentGateway {
+	return &FakePaymentGateway{
+		failures:       make(map[string]error),
+		authorizations: make(map[string]*fakeAuthorization),
+		collected:      make(map[string]float64),
+		refunded:       make(map[string]float64),
+	}
+}
+
+// FailCard makes charges and authorizations on the card fail with err, or with
+// ErrCardDeclined when err is nil.
+func (g *FakePaymentGateway) FailCard(cardToken string, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err == nil {
+		err = ErrCardDeclined
+	}
+	g.failures[cardToken] = err
+}
+
+// SucceedCard removes the failure scripted for the card.
+func (g *FakePaymentGateway) SucceedCard(cardToken string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.failures, cardToken)
+}
+
+// Operations returns the accepted calls in the order they were made.
+func (g *FakePaymentGateway) Operations() []FakeOperation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return append([]FakeOperation(nil), g.operations...)
+}
+
+func (g *FakePaymentGateway) Charge(cardToken string, amount float64) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.check(cardToken, amount); err != nil {
+		return "", err
+	}
+
+	reference := g.record(FakeCharge, "ch", cardToken, amount)
+	g.collected[reference] = amount
+	return reference, nil
+}
+
+func (g *FakePaymentGateway) Authorize(cardToken string, amount float64) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.check(cardToken, amount); err != nil {
+		return "", err
+	}
+
+	reference := g.record(FakeAuthorize, "auth", cardToken, amount)
+	g.authorizations[reference] = &fakeAuthorization{cardToken: cardToken, amount: amount}
+	return reference, nil
+}
+
+func (g *FakePaymentGateway) Capture(authorizationId string, amount float64) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	authorization, ok := g.authorizations[authorizationId]
+	if !ok {
+		return "", fmt.Errorf("authorization %s not found", authorizationId)
+	}
+	if authorization.captured {
+		return "", fmt.Errorf("authorization %s was already captured", authorizationId)
+	}
+	if amount <= 0 || amount > authorization.amount {
+		return "", fmt.Errorf("capture amount must be between 0 and the authorized %.2f", authorization.amount)
+	}
+
+	authorization.captured = true
+	reference := g.record(FakeCapture, "ch", authorization.cardToken, amount)
+	g.collected[reference] = amount
+	return reference, nil
+}
+
+func (g *FakePaymentGateway) Refund(chargeId string, amount float64) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	collected, ok := g.collected[chargeId]
+	if !ok {
+		return "", fmt.Errorf("charge %s not found", chargeId)
+	}
+	if amount <= 0 || g.refunded[chargeId]+amount > collected {
+		return "", fmt.Errorf("refund exceeds the %.2f left on charge %s", collected-g.refunded[chargeId], chargeId)
+	}
+
+	g.refunded[chargeId] += amount
+	return g.record(FakeRefund, "re", "", amount), nil
+}
+
+func (g *FakePaymentGateway) check(cardToken string, amount float64) error {
+	if err, ok := g.failures[cardToken]; ok {
+		return err
+	}
+	if amount <= 0 {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+	return nil
+}
+
+func (g *FakePaymentGateway) record(kind string, prefix string, cardToken string, amount float64) string {
+	g.sequence++
+	reference := fmt.Sprintf("fake_%s_%d", prefix, g.sequence)
+	g.operations = append(g.operations, FakeOperation{Kind: kind, Reference: reference, CardToken: cardToken, Amount: amount})
+	return reference
+}
@@ -0,0 +1,78 @@
+package gateways
+
+import (
+	"errors"
+	"testing"
+)
+
+const testCard = "tok_test"
+
+func TestFakeGatewayScriptedCards(t *testing.T) {
+	gateway := NewFakePaymentGateway()
+	declined := "tok_declined"
+	gateway.FailCard(declined, nil)
+
+	if _, err := gateway.Charge(declined, 10); !errors.Is(err, ErrCardDeclined) {
+		t.Fatalf("expected declined card, got %v", err)
+	}
+	if _, err := gateway.Authorize(declined, 10); !errors.Is(err, ErrCardDeclined) {
+		t.Fatalf("expected declined authorization, got %v", err)
+	}
+
+	reference, err := gateway.Charge(testCard, 10)
+	if err != nil || reference != "fake_ch_1" {
+		t.Fatalf("expected first charge fake_ch_1, got %q %v", reference, err)
+	}
+
+	gateway.SucceedCard(declined)
+	if _, err := gateway.Charge(declined, 10); err != nil {
+		t.Fatalf("expected card to succeed once unscripted, got %v", err)
+	}
+
+	if operations := gateway.Operations(); len(operations) != 2 {
+		t.Fatalf("expected 2 accepted operations, got %d", len(operations))
+	}
+}
+
+func TestFakeGatewayRefundsUpToCollected(t *testing.T) {
+	gateway := NewFakePaymentGateway()
+	chargeId, err := gateway.Charge(testCard, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := gateway.Refund(chargeId, 60); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gateway.Refund(chargeId, 40); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gateway.Refund(chargeId, 0.01); err == nil {
+		t.Fatal("expected refund over the collected amount to fail")
+	}
+	if _, err := gateway.Refund("unknown", 1); err == nil {
+		t.Fatal("expected refund of an unknown charge to fail")
+	}
+}
+
+func TestFakeGatewayCapturesOnce(t *testing.T) {
+	gateway := NewFakePaymentGateway()
+	authorizationId, err := gateway.Authorize(testCard, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := gateway.Capture(authorizationId, 60); err == nil {
+		t.Fatal("expected capture over the authorized amount to fail")
+	}
+	chargeId, err := gateway.Capture(authorizationId, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gateway.Capture(authorizationId, 10); err == nil {
+		t.Fatal("expected second capture to fail")
+	}
+	if _, err := gateway.Refund(chargeId, 30); err != nil {
+		t.Fatal(err)
+	}
+}
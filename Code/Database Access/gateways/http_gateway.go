@@ -0,0 +1,131 @@
+package gateways
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"pocketbase_go/logger"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const defaultHttpGatewayTimeout = 10 * time.Second
+
+// HttpPaymentGateway talks to the Payment-Module over HTTP.
+type HttpPaymentGateway struct {
+	paymentUrl   string
+	refundUrl    string
+	authorizeUrl string
+	captureUrl   string
+	client       *http.Client
+	// asyncSettlement asks the Payment-Module to settle charges and refunds in
+	// the background and report them to the webhook endpoint.
+	asyncSettlement bool
+}
+
+type gatewayResponse struct {
+	Id string `json:"id"`
+}
+
+func (g *HttpPaymentGateway) SetConfigValues(paymentUrl string, refundUrl string, authorizeUrl string, captureUrl string, timeout time.Duration, asyncSettlement bool) {
+	g.paymentUrl = paymentUrl
+	g.refundUrl = refundUrl
+	g.authorizeUrl = authorizeUrl
+	g.captureUrl = captureUrl
+	if timeout <= 0 {
+		timeout = defaultHttpGatewayTimeout
+	}
+	g.client = &http.Client{Timeout: timeout}
+	g.asyncSettlement = asyncSettlement
+}
+
+func (g *HttpPaymentGateway) Charge(cardToken string, amount float64) (string, error) {
+	return g.post(g.paymentUrl, map[string]interface{}{
+		"cardToken": cardToken,
+		"price":     amount,
+		"async":     g.asyncSettlement,
+	})
+}
+
+func (g *HttpPaymentGateway) Authorize(cardToken string, amount float64) (string, error) {
+	return g.post(g.authorizeUrl, map[string]interface{}{
+		"cardToken": cardToken,
+		"price":     amount,
+	})
+}
+
+func (g *HttpPaymentGateway) Capture(authorizationId string, amount float64) (string, error) {
+	return g.post(g.captureUrl, map[string]interface{}{
+		"authorizationId": authorizationId,
+		"amount":          amount,
+	})
+}
+
+func (g *HttpPaymentGateway) Refund(chargeId string, amount float64) (string, error) {
+	return g.post(g.refundUrl, map[string]interface{}{
+		"chargeId": chargeId,
+		"amount":   amount,
+		"async":    g.asyncSettlement,
+	})
+}
+
+func (g *HttpPaymentGateway) post(url string, requestBody map[string]interface{}) (string, error) {
+	bodyJSON, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(bodyJSON))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var written atomic.Bool
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				written.Store(true)
+			}
+		},
+	}))
+
+	client := g.client
+	if client == nil {
+		client = &http.Client{Timeout: defaultHttpGatewayTimeout}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", transportError(err, written.Load())
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		err := fmt.Errorf("Something went wrong: %d %s", resp.StatusCode, strings.TrimSpace(string(responseBody)))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return "", &UnavailableError{Err: err, Sent: true}
+		}
+		if resp.StatusCode == http.StatusPaymentRequired {
+			return "", fmt.Errorf("%w: %s", ErrCardDeclined, strings.TrimSpace(string(responseBody)))
+		}
+		return "", err
+	}
+
+	// The operation went through, without its reference it is neither a success nor a failure
+	var response gatewayResponse
+	if err := json.Unmarshal(responseBody, &response); err != nil || response.Id == "" {
+		logger.Error("Gateway: Payment-Module response has no reference: ", string(responseBody))
+		return "", ErrPaymentOutcomeUnknown
+	}
+
+	if resp.StatusCode == http.StatusAccepted {
+		return response.Id, ErrPaymentPending
+	}
+	return response.Id, nil
+}
@@ -0,0 +1,88 @@
+package gateways
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHttpGatewayResponses(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    int
+		body      string
+		reference string
+		err       error
+	}{
+		{"charged", http.StatusOK, `{"id":"ch_1"}`, "ch_1", nil},
+		{"settled later", http.StatusAccepted, `{"id":"ch_1"}`, "ch_1", ErrPaymentPending},
+		{"unparseable body", http.StatusOK, `charged`, "", ErrPaymentOutcomeUnknown},
+		{"unparseable accepted body", http.StatusAccepted, ``, "", ErrPaymentOutcomeUnknown},
+		{"no reference", http.StatusOK, `{"status":"succeeded"}`, "", ErrPaymentOutcomeUnknown},
+		{"declined", http.StatusPaymentRequired, `insufficient funds`, "", ErrCardDeclined},
+	}
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			w.Write([]byte(c.body))
+		}))
+		gateway := HttpPaymentGateway{}
+		gateway.SetConfigValues(server.URL, server.URL, server.URL, server.URL, 0, false)
+
+		reference, err := gateway.Charge(testCard, 10)
+		if reference != c.reference || !errors.Is(err, c.err) || (c.err == nil && err != nil) {
+			t.Errorf("%s: expected %q %v, got %q %v", c.name, c.reference, c.err, reference, err)
+		}
+		server.Close()
+	}
+}
+
+func TestHttpGatewayUnknownOutcomeIsPending(t *testing.T) {
+	if !errors.Is(ErrPaymentOutcomeUnknown, ErrPaymentPending) {
+		t.Errorf("expected an unknown outcome to count as a pending payment")
+	}
+	if IsUnavailable(ErrPaymentOutcomeUnknown) || retryable(ErrPaymentOutcomeUnknown, false) {
+		t.Errorf("expected an unknown outcome never to be retried")
+	}
+}
+
+func TestHttpGatewayTransportErrors(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	untrusted := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer untrusted.Close()
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	cases := []struct {
+		name string
+		url  string
+		sent bool
+	}{
+		{"connection refused", closed.URL, false},
+		{"tls handshake failed", untrusted.URL, false},
+		{"timed out after sending", slow.URL, true},
+	}
+	for _, c := range cases {
+		gateway := HttpPaymentGateway{}
+		gateway.SetConfigValues(c.url, c.url, c.url, c.url, 100*time.Millisecond, false)
+
+		_, err := gateway.Charge(testCard, 10)
+		var unavailable *UnavailableError
+		if !errors.As(err, &unavailable) {
+			t.Errorf("%s: expected the provider to be unavailable, got %v", c.name, err)
+			continue
+		}
+		if unavailable.Sent != c.sent {
+			t.Errorf("%s: expected sent %v, got %v", c.name, c.sent, unavailable.Sent)
+		}
+	}
+}
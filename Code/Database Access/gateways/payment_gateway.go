@@ -0,0 +1,15 @@
+package gateways
+
+// PaymentGateway moves money through a payment provider. Every successful call
+// returns the provider reference of the operation. Cards are only known by the
+// token the provider issued for them.
+type PaymentGateway interface {
+	// Charge captures the amount from the tokenized card right away.
+	Charge(cardToken string, amount float64) (string, error)
+	// Authorize holds the amount on the tokenized card until it is captured.
+	Authorize(cardToken string, amount float64) (string, error)
+	// Capture collects up to the authorized amount of a previous authorization.
+	Capture(authorizationId string, amount float64) (string, error)
+	// Refund returns part or all of a previous charge or capture.
+	Refund(chargeId string, amount float64) (string, error)
+}
@@ -0,0 +1,94 @@
+package gateways
+
+import (
+	"errors"
+	"pocketbase_go/logger"
+	"time"
+)
+
+// ResilientPaymentGateway guards another gateway with a circuit breaker and
+// retries failed calls with a bounded exponential backoff. Only calls that can
+// not move money twice are retried: the ones that never reached the provider
+// and captures, since an authorization can only be captured once.
+type ResilientPaymentGateway struct {
+	Gateway     PaymentGateway
+	Breaker     *CircuitBreaker
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	sleep       func(time.Duration)
+}
+
+func (g *ResilientPaymentGateway) SetConfigValues(maxAttempts int, baseDelay time.Duration, maxDelay time.Duration) {
+	g.maxAttempts = maxAttempts
+	g.baseDelay = baseDelay
+	g.maxDelay = maxDelay
+}
+
+func (g *ResilientPaymentGateway) Charge(cardToken string, amount float64) (string, error) {
+	return g.call(false, func() (string, error) {
+		return g.Gateway.Charge(cardToken, amount)
+	})
+}
+
+func (g *ResilientPaymentGateway) Authorize(cardToken string, amount float64) (string, error) {
+	return g.call(false, func() (string, error) {
+		return g.Gateway.Authorize(cardToken, amount)
+	})
+}
+
+func (g *ResilientPaymentGateway) Capture(authorizationId string, amount float64) (string, error) {
+	return g.call(true, func() (string, error) {
+		return g.Gateway.Capture(authorizationId, amount)
+	})
+}
+
+func (g *ResilientPaymentGateway) Refund(chargeId string, amount float64) (string, error) {
+	return g.call(false, func() (string, error) {
+		return g.Gateway.Refund(chargeId, amount)
+	})
+}
+
+func (g *ResilientPaymentGateway) call(idempotent bool, operation func() (string, error)) (string, error) {
+	for attempt := 1; ; attempt++ {
+		var reference string
+		err := g.Breaker.Execute(func() error {
+			var err error
+			reference, err = operation()
+			return err
+		})
+		if err == nil {
+			return reference, nil
+		}
+
+		// The reference is kept since a pending operation comes with one
+		if attempt >= g.maxAttempts || !retryable(err, idempotent) || g.Breaker.State() == BreakerOpen {
+			return reference, err
+		}
+
+		delay := g.backoff(attempt)
+		logger.Warn("Gateway: Payment call failed, attempt ", attempt, " of ", g.maxAttempts, ", retrying in ", delay, ": ", err)
+		if g.sleep != nil {
+			g.sleep(delay)
+		} else {
+			time.Sleep(delay)
+		}
+	}
+}
+
+// backoff doubles the delay after every attempt up to maxDelay.
+func (g *ResilientPaymentGateway) backoff(attempt int) time.Duration {
+	delay := g.baseDelay
+	for i := 1; i < attempt && delay < g.maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, g.maxDelay)
+}
+
+func retryable(err error, idempotent bool) bool {
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) {
+		return false
+	}
+	return idempotent || !unavailable.Sent
+}
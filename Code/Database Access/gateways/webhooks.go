@@ -0,0 +1,67 @@
+package gateways
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader carries the signature of the callbacks sent by the
+// Payment-Module, formatted as "t=<unix seconds>,v1=<hex HMAC-SHA256>". The
+// HMAC covers the timestamp and the body joined by a dot, so an old callback
+// cannot be replayed with a new timestamp.
+const WebhookSignatureHeader = "X-Payment-Signature"
+
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// SignWebhook returns the signature header value for the body sent at timestamp.
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", unix, webhookMac(secret, unix, body))
+}
+
+// VerifyWebhookSignature checks that the body was signed with the secret less
+// than tolerance ago.
+func VerifyWebhookSignature(secret string, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	if secret == "" {
+		return fmt.Errorf("%w: no webhook secret is configured", ErrInvalidWebhookSignature)
+	}
+
+	var unix, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || signature == "" {
+		return fmt.Errorf("%w: malformed header", ErrInvalidWebhookSignature)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp is outside the tolerance", ErrInvalidWebhookSignature)
+	}
+
+	expected := webhookMac(secret, unix, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
+
+func webhookMac(secret string, unix string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
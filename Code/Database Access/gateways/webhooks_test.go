@@ -0,0 +1,30 @@
+package gateways
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":"evt_1","type":"charge","status":"succeeded"}`)
+	header := SignWebhook("secret", now, body)
+
+	if err := VerifyWebhookSignature("secret", header, body, time.Minute, now.Add(30*time.Second)); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+
+	cases := map[string]error{
+		"tampered body": VerifyWebhookSignature("secret", header, []byte(`{"id":"evt_1","type":"charge","status":"failed"}`), time.Minute, now),
+		"wrong secret":  VerifyWebhookSignature("other", header, body, time.Minute, now),
+		"too old":       VerifyWebhookSignature("secret", header, body, time.Minute, now.Add(2*time.Minute)),
+		"malformed":     VerifyWebhookSignature("secret", "v1=abc", body, time.Minute, now),
+		"no secret":     VerifyWebhookSignature("", header, body, time.Minute, now),
+	}
+	for name, err := range cases {
+		if !errors.Is(err, ErrInvalidWebhookSignature) {
+			t.Errorf("%s: expected invalid signature, got %v", name, err)
+		}
+	}
+}
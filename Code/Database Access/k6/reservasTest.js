@@ -10,6 +10,7 @@ export const options = {
 };
 
 const BASE_URL = "http://127.0.0.1:8090";
+const PAYMENT_MODULE_URL = "http://127.0.0.1:8085";
 const TOKEN = "test_token_one";
 const propertyId = "qy40nbutxtxlpcx";
 
@@ -19,23 +20,31 @@ const headers = {
 };
 
 export function setup() {
+  // Card details only go to the Payment-Module, the API gets a token
+  const tokenRes = http.post(
+    `${PAYMENT_MODULE_URL}/tokenize`,
+    JSON.stringify({
+      cardNumber: "4111111111111111",
+      name: "Ruperto Rocanrol",
+      cvv: "123",
+      expDate: "2030-06",
+    }),
+    { headers: { "Content-Type": "application/json" } }
+  );
+  const cardToken = tokenRes.json().token;
+
   // Pay for the property if it ain't paid
   const paymentPayload = JSON.stringify({
     propertyId,
-    cardInfo: {
-      cardNumber: "1234567812345678",
-      name: "Ruperto Rocanrol",
-      cvv: "123",
-      expDate: "2025-06",
-    },
+    cardToken,
   });
 
   http.post(`${BASE_URL}/property/pay`, paymentPayload, { headers });
 
-  return { propertyId };
+  return { propertyId, cardToken };
 }
 
-export default function () {
+export default function (data) {
   const EMAILTOKEN = generateRandomEmail();
   const randomDay = generateRandomDate();
   const reservationPayload = JSON.stringify({
@@ -101,12 +110,7 @@ export default function () {
   // Pay for the reservation
   const reservationPaymentPayload = JSON.stringify({
     reservationId: reservationId,
-    cardInfo: {
-      cardNumber: "1234567812345678",
-      name: "Ruperto Rocanrol",
-      cvv: "123",
-      expDate: "2025-06",
-    },
+    cardToken: data.cardToken,
   });
 
   const reservationPaymentRes = http.post(
@@ -17,7 +17,9 @@ import (
 
 	"pocketbase_go/config"
 	"pocketbase_go/controllers"
+	"pocketbase_go/gateways"
 	logger "pocketbase_go/logger"
+	_ "pocketbase_go/migrations"
 	repositories "pocketbase_go/repos/implementations"
 	"pocketbase_go/services"
 	"pocketbase_go/workers"
@@ -69,6 +71,13 @@ var (
 		Help:      "Counts failed reservation payments",
 	})
 
+	paymentCircuitBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "go_metrics",
+		Subsystem: "prometheus",
+		Name:      "PaymentCircuitBreakerState",
+		Help:      "State of the payment circuit breaker: 0 closed, 1 half-open, 2 open",
+	})
+
 	opsRequested = promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: "go_metrics",
 		Subsystem: "prometheus",
@@ -168,15 +177,46 @@ func main() {
 
 	defaultRefundPercentage := viper.GetFloat64("default_refund_percentage")
 	defaultCancellationDays := viper.GetInt("default_cancellation_days")
+	defaultPaymentDeadlineHours := viper.GetInt("default_payment_deadline_hours")
+	paymentWarningHours := viper.GetInt("payment_warning_hours")
 
 	propertyImagesUrl := viper.GetString("property_images_url")
 	propertyImagesDir := viper.GetString("property_images_dir")
 	propertyImagesCompressionScale := viper.GetString("property_images_compression_scale")
 
+	serviceFeePercentage := viper.GetFloat64("service_fee_percentage")
+	taxPercentage := viper.GetFloat64("tax_percentage")
+	platformCommissionPercentage := viper.GetFloat64("platform_commission_percentage")
+	payoutDelayHours := viper.GetInt("payout_delay_hours")
+
 	paymentURL := viper.GetString("payment_url")
 	refundURL := viper.GetString("refund_url")
+	authorizeURL := viper.GetString("authorize_url")
+	captureURL := viper.GetString("capture_url")
+	paymentCurrency := viper.GetString("payment_currency")
+	paymentTimeout := time.Duration(viper.GetInt("payment_timeout_seconds")) * time.Second
+	paymentBreakerFailureThreshold := viper.GetInt("payment_breaker_failure_threshold")
+	paymentBreakerOpenTimeout := time.Duration(viper.GetInt("payment_breaker_open_seconds")) * time.Second
+	paymentRetryMaxAttempts := viper.GetInt("payment_retry_max_attempts")
+	paymentRetryBaseDelay := time.Duration(viper.GetInt("payment_retry_base_delay_ms")) * time.Millisecond
+	paymentRetryMaxDelay := time.Duration(viper.GetInt("payment_retry_max_delay_ms")) * time.Millisecond
+	ownerResponseSlaHours := viper.GetInt("owner_response_sla_hours")
+	hostCancellationPenaltyPercentage := viper.GetFloat64("host_cancellation_penalty_percentage")
+	noShowCutoffHours := viper.GetInt("no_show_cutoff_hours")
+	notificationMaxAttempts := viper.GetInt("notification_max_attempts")
+	balanceRetryHours := viper.GetInt("balance_retry_hours")
+	balanceMaxAttempts := viper.GetInt("balance_max_attempts")
+	paymentWorkers := viper.GetInt("payment_workers")
+	paymentAsyncSettlement := viper.GetBool("payment_async_settlement")
+	paymentWebhookSecret := viper.GetString("payment_webhook_secret")
 
 	initLogger()
+	// Anyone who knows the secret can settle payments, so the webhook is only
+	// exposed with a secret of our own. Async payments cannot settle without it.
+	paymentWebhookEnabled := paymentWebhookSecret != "" && paymentWebhookSecret != "change-me"
+	if !paymentWebhookEnabled && paymentAsyncSettlement {
+		logger.Fatal("payment_async_settlement requires payment_webhook_secret to be set")
+	}
 	mongoClient, mongoErr := initMongo(mongoDatasource)
 	app := pocketbase.New()
 	initFileServer(app)
@@ -202,26 +242,57 @@ func main() {
 	reservationsRepo := repositories.PocketReservationRepo{Db: *app}
 	sensorRepo := repositories.PocketSensorRepo{Db: *app, Cache: redisClient}
 	settingsRepo := repositories.PocketSettingsRepo{Db: *app}
-	settingsRepo.SetConfigValues(defaultRefundPercentage, defaultCancellationDays)
+	settingsRepo.SetConfigValues(defaultRefundPercentage, defaultCancellationDays, defaultPaymentDeadlineHours)
+	priceRulesRepo := repositories.PocketPriceRulesRepo{Db: *app}
+	hostCancellationsRepo := repositories.PocketHostCancellationsRepo{Db: *app}
+	scheduledNotificationsRepo := repositories.PocketScheduledNotificationsRepo{Db: *app}
+	guestsRepo := repositories.PocketGuestsRepo{Db: *app}
+	idempotencyRepo := repositories.PocketIdempotencyRepo{Db: *app, Cache: redisClient}
+	paymentSchedulesRepo := repositories.PocketPaymentSchedulesRepo{Db: *app}
+	messagesRepo := repositories.PocketMessagesRepo{Db: *app}
+	paymentsRepo := repositories.PocketPaymentsRepo{Db: *app}
+	paymentsRepo.SetConfigValues(paymentCurrency)
+	paymentEventsRepo := repositories.PocketPaymentEventsRepo{Db: *app}
+	payoutsRepo := repositories.PocketPayoutsRepo{Db: *app}
+
+	// Gateways
+	httpPaymentGateway := gateways.HttpPaymentGateway{}
+	httpPaymentGateway.SetConfigValues(paymentURL, refundURL, authorizeURL, captureURL, paymentTimeout, paymentAsyncSettlement)
+	paymentBreaker := gateways.NewCircuitBreaker(paymentBreakerFailureThreshold, paymentBreakerOpenTimeout)
+	paymentBreaker.OnStateChange = func(state gateways.BreakerState) {
+		paymentCircuitBreakerState.Set(float64(state))
+	}
+	paymentGateway := gateways.ResilientPaymentGateway{Gateway: &httpPaymentGateway, Breaker: paymentBreaker}
+	paymentGateway.SetConfigValues(paymentRetryMaxAttempts, paymentRetryBaseDelay, paymentRetryMaxDelay)
 
 	// Services
-	propertyService := services.PropertyService{Repo: &propertyRepo, UserRepo: &userRepo}
+	notificationService := services.NewNotificationService(redisClient)
+	pricingService := services.PricingService{PropertiesRepo: &propertyRepo, SettingsRepo: &settingsRepo, PriceRulesRepo: &priceRulesRepo}
+	pricingService.SetConfigValues(serviceFeePercentage, taxPercentage, platformCommissionPercentage)
+	propertyService := services.PropertyService{Repo: &propertyRepo, UserRepo: &userRepo, PriceRulesRepo: &priceRulesRepo}
 	authService := services.AuthService{Repo: &userRepo}
-	reservationService := services.ReservationService{ReservationRepo: &reservationsRepo, UserRepo: &userRepo, SettingsRepo: &settingsRepo, PropertiesRepo: &propertyRepo}
-	reservationService.SetConfigValues(refundURL)
+	idempotencyService := services.IdempotencyService{Repo: &idempotencyRepo}
+	reminderService := services.ReminderService{Repo: &scheduledNotificationsRepo, SettingsRepo: &settingsRepo, PropertiesRepo: &propertyRepo, NotificationService: notificationService}
+	reminderService.SetConfigValues(paymentWarningHours, notificationMaxAttempts)
+	reservationService := services.ReservationService{ReservationRepo: &reservationsRepo, UserRepo: &userRepo, SettingsRepo: &settingsRepo, PropertiesRepo: &propertyRepo, NotificationService: notificationService, PricingService: &pricingService, HostCancellationsRepo: &hostCancellationsRepo, ReminderService: &reminderService, GuestsRepo: &guestsRepo, PaymentSchedulesRepo: &paymentSchedulesRepo, PaymentsRepo: &paymentsRepo, Gateway: &paymentGateway}
+	reservationService.SetConfigValues(ownerResponseSlaHours, hostCancellationPenaltyPercentage, noShowCutoffHours)
 	sensorService := services.SensorService{Repo: &sensorRepo}
-	paymentService := services.PaymentService{UsersRepo: &userRepo, PropertyRepo: &propertyRepo, ReservationRepo: &reservationsRepo}
-	paymentService.SetConfigValues(paymentURL)
-	reportsService := services.ReportsService{ReservationRepo: &reservationsRepo, PropertiesRepo: &propertyRepo, UsersRepo: &userRepo, ReportsRepo: reportsRepo, SensorRepo: &sensorRepo}
-	notificationService := services.NewNotificationService(redisClient)
+	paymentService := services.PaymentService{UsersRepo: &userRepo, PropertyRepo: &propertyRepo, ReservationRepo: &reservationsRepo, PricingService: &pricingService, ReminderService: &reminderService, PaymentSchedulesRepo: &paymentSchedulesRepo, NotificationService: notificationService, PaymentsRepo: &paymentsRepo, Gateway: &paymentGateway, Worker: worker, PaymentEventsRepo: &paymentEventsRepo}
+	paymentService.SetConfigValues(balanceRetryHours, balanceMaxAttempts)
+	messagesService := services.MessagesService{Repo: &messagesRepo, ReservationRepo: &reservationsRepo, PropertiesRepo: &propertyRepo, UserRepo: &userRepo, NotificationService: notificationService}
+	payoutsService := services.PayoutsService{Repo: &payoutsRepo, ReservationRepo: &reservationsRepo, PaymentsRepo: &paymentsRepo, PropertiesRepo: &propertyRepo, UserRepo: &userRepo, PricingService: &pricingService, NotificationService: notificationService}
+	payoutsService.SetConfigValues(payoutDelayHours)
+	reportsService := services.ReportsService{ReservationRepo: &reservationsRepo, PropertiesRepo: &propertyRepo, UsersRepo: &userRepo, ReportsRepo: reportsRepo, SensorRepo: &sensorRepo, PricingService: &pricingService, HostCancellationsRepo: &hostCancellationsRepo, PaymentSchedulesRepo: &paymentSchedulesRepo}
 
 	// Controllers
-	propertyController := controllers.PropertyController{Service: &propertyService, PaymentService: &paymentService, AuthService: authService}
-	reservationsController := controllers.ReservationsController{ReservationsService: &reservationService, AuthService: authService, PaymentService: &paymentService}
+	propertyController := controllers.PropertyController{Service: &propertyService, PaymentService: &paymentService, AuthService: authService, IdempotencyService: &idempotencyService}
+	reservationsController := controllers.ReservationsController{ReservationsService: &reservationService, AuthService: authService, PaymentService: &paymentService, PricingService: &pricingService, IdempotencyService: &idempotencyService, MessagesService: &messagesService}
 	authController := controllers.AuthController{AuthService: authService}
 	sensorController := controllers.SensorController{Service: &sensorService, AuthService: authService}
 	reportsController := controllers.NewReportsController(authService, &reportsService, notificationService, worker)
-	notificationsController := controllers.NewNotificationsController(notificationService, &reservationService)
+	notificationsController := controllers.NewNotificationsController(notificationService, &reservationService, &reminderService)
+	paymentWebhooksController := controllers.NewPaymentWebhooksController(&paymentService, paymentWebhookSecret)
+	payoutsController := controllers.PayoutsController{Service: &payoutsService, AuthService: authService}
 
 	sensorController.InitSensorEndpoints(*app)
 	propertyController.InitPropertyEndpoints(*app)
@@ -229,13 +300,55 @@ func main() {
 	reportsController.InitReportsEndpoints(*app)
 	notificationsController.InitNotificationsEndpoints(*app)
 	authController.InitAuthEndpoints(*app)
+	if paymentWebhookEnabled {
+		paymentWebhooksController.InitPaymentWebhookEndpoints(*app)
+	} else {
+		logger.Warn("payment_webhook_secret is not set, payment webhooks are disabled")
+	}
+	payoutsController.InitPayoutEndpoints(*app)
+
+	if rabbitErr == nil {
+		if _, err := worker.Listen(paymentWorkers, "payments", reservationsController.ProcessPaymentJob); err != nil {
+			logger.Error("Error listening to the payments queue: ", err)
+		}
+	}
 
 	app.OnBeforeServe().Add(func(e *core.ServeEvent) error {
 		scheduler := cron.New()
 
-		err := scheduler.Add("reservationDiscard", "@daily", func() {
+		err := scheduler.Add("reservationDiscard", "*/15 * * * *", func() {
 			reservationsController.AutoCancelReservations()
 		})
+		if err == nil {
+			err = scheduler.Add("reservationExpire", "@hourly", func() {
+				reservationsController.ExpirePendingReservations()
+			})
+		}
+		if err == nil {
+			err = scheduler.Add("reservationNoShow", "@hourly", func() {
+				reservationsController.ProcessNoShows()
+			})
+		}
+		if err == nil {
+			err = scheduler.Add("reservationAutoCheckOut", "@hourly", func() {
+				reservationsController.AutoCheckOutReservations()
+			})
+		}
+		if err == nil {
+			err = scheduler.Add("reservationBalance", "@hourly", func() {
+				reservationsController.ChargeDueBalances()
+			})
+		}
+		if err == nil {
+			err = scheduler.Add("ownerPayouts", "@hourly", func() {
+				payoutsController.ProcessPayouts()
+			})
+		}
+		if err == nil {
+			err = scheduler.Add("reminderSender", "*/5 * * * *", func() {
+				notificationsController.SendDueReminders()
+			})
+		}
 
 		if err != nil {
 			logger.Error("Error scheduling job:", err)
@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		return addFields(db, "properties",
+			&schema.SchemaField{Name: "instantBook", Type: schema.FieldTypeBool},
+			jsonField("instantBookRules"),
+		)
+	}, func(db dbx.Builder) error {
+		return removeFields(db, "properties", "instantBook", "instantBookRules")
+	})
+}
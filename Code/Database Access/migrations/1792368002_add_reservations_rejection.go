@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		if err := addSelectValues(db, "reservations", "status", "Rejected", "Expired"); err != nil {
+			return err
+		}
+		return addFields(db, "reservations",
+			&schema.SchemaField{Name: "rejection_reason", Type: schema.FieldTypeText},
+		)
+	}, func(db dbx.Builder) error {
+		return removeFields(db, "reservations", "rejection_reason")
+	})
+}
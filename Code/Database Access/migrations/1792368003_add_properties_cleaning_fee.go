@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		minFee := 0.0
+		return addFields(db, "properties",
+			&schema.SchemaField{Name: "cleaningFee", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: &minFee}},
+		)
+	}, func(db dbx.Builder) error {
+		return removeFields(db, "properties", "cleaningFee")
+	})
+}
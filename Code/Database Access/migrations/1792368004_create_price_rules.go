@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		properties, err := daos.New(db).FindCollectionByNameOrId("properties")
+		if err != nil {
+			return err
+		}
+
+		minValue := 0.0
+		return createCollection(db, "price_rules",
+			&schema.SchemaField{Name: "propertyId", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: properties.Id, CascadeDelete: true, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "type", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"Season", "Weekend", "SpecialDay"}}},
+			&schema.SchemaField{Name: "dateFrom", Type: schema.FieldTypeDate, Options: &schema.DateOptions{}},
+			&schema.SchemaField{Name: "dateTo", Type: schema.FieldTypeDate, Options: &schema.DateOptions{}},
+			jsonField("weekdays"),
+			&schema.SchemaField{Name: "price", Type: schema.FieldTypeNumber, Required: true, Options: &schema.NumberOptions{Min: &minValue}},
+			&schema.SchemaField{Name: "minNights", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: &minValue, NoDecimal: true}},
+		)
+	}, func(db dbx.Builder) error {
+		return deleteCollection(db, "price_rules")
+	})
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		return addFields(db, "properties", jsonField("bookingRules"))
+	}, func(db dbx.Builder) error {
+		return removeFields(db, "properties", "bookingRules")
+	})
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		err := addFields(db, "reservations",
+			&schema.SchemaField{Name: "cancellation_reason", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "cancelled_by", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+		)
+		if err != nil {
+			return err
+		}
+
+		dao := daos.New(db)
+		reservations, err := dao.FindCollectionByNameOrId("reservations")
+		if err != nil {
+			return err
+		}
+		properties, err := dao.FindCollectionByNameOrId("properties")
+		if err != nil {
+			return err
+		}
+		users, err := dao.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		minValue := 0.0
+		return createCollection(db, "host_cancellations",
+			&schema.SchemaField{Name: "reservationId", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: reservations.Id, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "propertyId", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: properties.Id, CascadeDelete: true, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "owner", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: users.Id, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "cancelledBy", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"Owner", "Admin"}}},
+			&schema.SchemaField{Name: "reason", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "penalty", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: &minValue}},
+		)
+	}, func(db dbx.Builder) error {
+		if err := deleteCollection(db, "host_cancellations"); err != nil {
+			return err
+		}
+		return removeFields(db, "reservations", "cancellation_reason", "cancelled_by")
+	})
+}
@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		if err := addSelectValues(db, "reservations", "status", "NoShow"); err != nil {
+			return err
+		}
+		return addFields(db, "reservations",
+			&schema.SchemaField{Name: "needs_review", Type: schema.FieldTypeBool},
+		)
+	}, func(db dbx.Builder) error {
+		return removeFields(db, "reservations", "needs_review")
+	})
+}
@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		minHours := 1.0
+		return createCollection(db, "payment_deadline_settings",
+			&schema.SchemaField{Name: "country", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{Min: types.Pointer(2), Max: types.Pointer(2), Pattern: "^[A-Z]{2}$"}},
+			&schema.SchemaField{Name: "hours", Type: schema.FieldTypeNumber, Required: true, Options: &schema.NumberOptions{Min: &minHours, NoDecimal: true}},
+		)
+	}, func(db dbx.Builder) error {
+		return deleteCollection(db, "payment_deadline_settings")
+	})
+}
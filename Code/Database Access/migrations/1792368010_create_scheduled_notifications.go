@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		minValue := 0.0
+		err := createCollection(db, "scheduled_notifications",
+			&schema.SchemaField{Name: "dedupeKey", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "kind", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "reservationId", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "email", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "message", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "dueAt", Type: schema.FieldTypeDate, Required: true, Options: &schema.DateOptions{}},
+			&schema.SchemaField{Name: "sentAt", Type: schema.FieldTypeDate, Options: &schema.DateOptions{}},
+			&schema.SchemaField{Name: "attempts", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: &minValue, NoDecimal: true}},
+			&schema.SchemaField{Name: "lastError", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+		)
+		if err != nil {
+			return err
+		}
+
+		// The unique dedupe key keeps a reminder from being scheduled twice
+		dao := daos.New(db)
+		collection, err := dao.FindCollectionByNameOrId("scheduled_notifications")
+		if err != nil {
+			return err
+		}
+		collection.Indexes = append(collection.Indexes,
+			"CREATE UNIQUE INDEX idx_scheduled_notifications_dedupe ON scheduled_notifications (dedupeKey)",
+			"CREATE INDEX idx_scheduled_notifications_due ON scheduled_notifications (sentAt, dueAt)",
+		)
+		if err := dao.SaveCollection(collection); err != nil {
+			return err
+		}
+
+		return addFields(db, "properties",
+			&schema.SchemaField{Name: "checkInInstructions", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+		)
+	}, func(db dbx.Builder) error {
+		if err := removeFields(db, "properties", "checkInInstructions"); err != nil {
+			return err
+		}
+		return deleteCollection(db, "scheduled_notifications")
+	})
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db)
+		reservations, err := dao.FindCollectionByNameOrId("reservations")
+		if err != nil {
+			return err
+		}
+
+		return createCollection(db, "reservation_guests",
+			&schema.SchemaField{Name: "reservationId", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: reservations.Id, CascadeDelete: true, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "name", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "lastName", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "documentType", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"Passport", "NationalId"}}},
+			&schema.SchemaField{Name: "documentNumber", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "birthDate", Type: schema.FieldTypeDate, Required: true, Options: &schema.DateOptions{}},
+			&schema.SchemaField{Name: "nationality", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+		)
+	}, func(db dbx.Builder) error {
+		return deleteCollection(db, "reservation_guests")
+	})
+}
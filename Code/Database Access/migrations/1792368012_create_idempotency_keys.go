@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		err := createCollection(db, "idempotency_keys",
+			&schema.SchemaField{Name: "key", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "requestHash", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "status", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"InProgress", "Completed"}}},
+			&schema.SchemaField{Name: "statusCode", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{NoDecimal: true}},
+			&schema.SchemaField{Name: "contentType", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "body", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "expiresAt", Type: schema.FieldTypeDate, Required: true, Options: &schema.DateOptions{}},
+		)
+		if err != nil {
+			return err
+		}
+
+		dao := daos.New(db)
+		collection, err := dao.FindCollectionByNameOrId("idempotency_keys")
+		if err != nil {
+			return err
+		}
+		collection.Indexes = append(collection.Indexes,
+			"CREATE UNIQUE INDEX idx_idempotency_keys_key ON idempotency_keys (key)",
+			"CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys (expiresAt)",
+		)
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		return deleteCollection(db, "idempotency_keys")
+	})
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		if err := addFields(db, "properties", jsonField("paymentTerms")); err != nil {
+			return err
+		}
+
+		if err := addSelectValues(db, "reservations", "status", "PartiallyPaid"); err != nil {
+			return err
+		}
+
+		dao := daos.New(db)
+		reservations, err := dao.FindCollectionByNameOrId("reservations")
+		if err != nil {
+			return err
+		}
+
+		minValue := 0.0
+		return createCollection(db, "payment_schedules",
+			&schema.SchemaField{Name: "reservationId", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: reservations.Id, CascadeDelete: true, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "kind", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"Deposit", "Balance", "Full"}}},
+			&schema.SchemaField{Name: "amount", Type: schema.FieldTypeNumber, Required: true, Options: &schema.NumberOptions{Min: &minValue}},
+			&schema.SchemaField{Name: "dueDate", Type: schema.FieldTypeDate, Required: true, Options: &schema.DateOptions{}},
+			&schema.SchemaField{Name: "status", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"Pending", "Paid", "Failed", "Cancelled"}}},
+			&schema.SchemaField{Name: "paidAt", Type: schema.FieldTypeDate, Options: &schema.DateOptions{}},
+			&schema.SchemaField{Name: "attempts", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: &minValue, NoDecimal: true}},
+			&schema.SchemaField{Name: "lastError", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "cardToken", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+		)
+	}, func(db dbx.Builder) error {
+		if err := deleteCollection(db, "payment_schedules"); err != nil {
+			return err
+		}
+		return removeFields(db, "properties", "paymentTerms")
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db)
+		reservations, err := dao.FindCollectionByNameOrId("reservations")
+		if err != nil {
+			return err
+		}
+
+		return createCollection(db, "reservation_messages",
+			&schema.SchemaField{Name: "reservationId", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: reservations.Id, CascadeDelete: true, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "senderId", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "senderRole", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"Tenant", "Owner"}}},
+			&schema.SchemaField{Name: "body", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+			jsonField("attachments"),
+			&schema.SchemaField{Name: "readAt", Type: schema.FieldTypeDate, Options: &schema.DateOptions{}},
+		)
+	}, func(db dbx.Builder) error {
+		return deleteCollection(db, "reservation_messages")
+	})
+}
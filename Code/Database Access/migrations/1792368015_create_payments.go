@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db)
+		reservations, err := dao.FindCollectionByNameOrId("reservations")
+		if err != nil {
+			return err
+		}
+		properties, err := dao.FindCollectionByNameOrId("properties")
+		if err != nil {
+			return err
+		}
+
+		err = createCollection(db, "payments",
+			&schema.SchemaField{Name: "reservationId", Type: schema.FieldTypeRelation, Options: &schema.RelationOptions{CollectionId: reservations.Id, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "propertyId", Type: schema.FieldTypeRelation, Options: &schema.RelationOptions{CollectionId: properties.Id, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "kind", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"Charge", "Refund"}}},
+			&schema.SchemaField{Name: "amount", Type: schema.FieldTypeNumber, Required: true, Options: &schema.NumberOptions{}},
+			&schema.SchemaField{Name: "currency", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "gatewayReference", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "status", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"Pending", "Succeeded", "Failed"}}},
+			&schema.SchemaField{Name: "failureReason", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+		)
+		if err != nil {
+			return err
+		}
+
+		payments, err := dao.FindCollectionByNameOrId("payments")
+		if err != nil {
+			return err
+		}
+		payments.Indexes = append(payments.Indexes,
+			"CREATE INDEX idx_payments_reservation ON payments (reservationId)",
+			"CREATE INDEX idx_payments_property ON payments (propertyId)",
+		)
+		return dao.SaveCollection(payments)
+	}, func(db dbx.Builder) error {
+		return deleteCollection(db, "payments")
+	})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db)
+		payments, err := dao.FindCollectionByNameOrId("payments")
+		if err != nil {
+			return err
+		}
+
+		return addFields(db, "payments",
+			&schema.SchemaField{Name: "chargeId", Type: schema.FieldTypeRelation, Options: &schema.RelationOptions{CollectionId: payments.Id, MaxSelect: types.Pointer(1)}},
+		)
+	}, func(db dbx.Builder) error {
+		return removeFields(db, "payments", "chargeId")
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		err := createCollection(db, "payment_events",
+			&schema.SchemaField{Name: "eventId", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "type", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"charge", "refund"}}},
+			&schema.SchemaField{Name: "reference", Type: schema.FieldTypeText, Required: true, Options: &schema.TextOptions{}},
+			&schema.SchemaField{Name: "status", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"succeeded", "failed"}}},
+		)
+		if err != nil {
+			return err
+		}
+
+		dao := daos.New(db)
+		collection, err := dao.FindCollectionByNameOrId("payment_events")
+		if err != nil {
+			return err
+		}
+		collection.Indexes = append(collection.Indexes,
+			"CREATE UNIQUE INDEX idx_payment_events_event ON payment_events (eventId)",
+		)
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		return deleteCollection(db, "payment_events")
+	})
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+// Payments keep the card token they were charged with, card numbers are
+// never stored.
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		return addFields(db, "payments",
+			&schema.SchemaField{Name: "cardToken", Type: schema.FieldTypeText, Options: &schema.TextOptions{}},
+		)
+	}, func(db dbx.Builder) error {
+		return removeFields(db, "payments", "cardToken")
+	})
+}
@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db)
+		reservations, err := dao.FindCollectionByNameOrId("reservations")
+		if err != nil {
+			return err
+		}
+		properties, err := dao.FindCollectionByNameOrId("properties")
+		if err != nil {
+			return err
+		}
+		users, err := dao.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		minValue := 0.0
+		err = createCollection(db, "payout_batches",
+			&schema.SchemaField{Name: "ownerId", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: users.Id, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "status", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"Processing", "Paid", "Failed"}}},
+			&schema.SchemaField{Name: "total", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: &minValue}},
+			&schema.SchemaField{Name: "count", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: &minValue, NoDecimal: true}},
+			&schema.SchemaField{Name: "processedAt", Type: schema.FieldTypeDate, Options: &schema.DateOptions{}},
+		)
+		if err != nil {
+			return err
+		}
+
+		batches, err := dao.FindCollectionByNameOrId("payout_batches")
+		if err != nil {
+			return err
+		}
+
+		err = createCollection(db, "payouts",
+			&schema.SchemaField{Name: "reservationId", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: reservations.Id, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "propertyId", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: properties.Id, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "ownerId", Type: schema.FieldTypeRelation, Required: true, Options: &schema.RelationOptions{CollectionId: users.Id, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "batchId", Type: schema.FieldTypeRelation, Options: &schema.RelationOptions{CollectionId: batches.Id, MaxSelect: types.Pointer(1)}},
+			&schema.SchemaField{Name: "gross", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: &minValue}},
+			&schema.SchemaField{Name: "commission", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: &minValue}},
+			&schema.SchemaField{Name: "fees", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{Min: &minValue}},
+			&schema.SchemaField{Name: "net", Type: schema.FieldTypeNumber, Options: &schema.NumberOptions{}},
+			&schema.SchemaField{Name: "status", Type: schema.FieldTypeSelect, Required: true, Options: &schema.SelectOptions{MaxSelect: 1, Values: []string{"Scheduled", "Batched", "Paid", "Cancelled"}}},
+			&schema.SchemaField{Name: "dueAt", Type: schema.FieldTypeDate, Required: true, Options: &schema.DateOptions{}},
+		)
+		if err != nil {
+			return err
+		}
+
+		payouts, err := dao.FindCollectionByNameOrId("payouts")
+		if err != nil {
+			return err
+		}
+		payouts.Indexes = append(payouts.Indexes,
+			"CREATE UNIQUE INDEX idx_payouts_reservation ON payouts (reservationId)",
+			"CREATE INDEX idx_payouts_owner ON payouts (ownerId)",
+		)
+		return dao.SaveCollection(payouts)
+	}, func(db dbx.Builder) error {
+		if err := deleteCollection(db, "payouts"); err != nil {
+			return err
+		}
+		return deleteCollection(db, "payout_batches")
+	})
+}
@@ -0,0 +1,101 @@
+package migrations
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+)
+
+const jsonMaxSize = 2000000
+
+func addFields(db dbx.Builder, collectionName string, fields ...*schema.SchemaField) error {
+	dao := daos.New(db)
+	collection, err := dao.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if collection.Schema.GetFieldByName(field.Name) != nil {
+			continue
+		}
+		collection.Schema.AddField(field)
+	}
+
+	return dao.SaveCollection(collection)
+}
+
+func removeFields(db dbx.Builder, collectionName string, fieldNames ...string) error {
+	dao := daos.New(db)
+	collection, err := dao.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range fieldNames {
+		if field := collection.Schema.GetFieldByName(name); field != nil {
+			collection.Schema.RemoveField(field.Id)
+		}
+	}
+
+	return dao.SaveCollection(collection)
+}
+
+func addSelectValues(db dbx.Builder, collectionName string, fieldName string, values ...string) error {
+	dao := daos.New(db)
+	collection, err := dao.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		return err
+	}
+
+	field := collection.Schema.GetFieldByName(fieldName)
+	if field == nil {
+		return fmt.Errorf("field %s not found in %s", fieldName, collectionName)
+	}
+
+	options, ok := field.Options.(*schema.SelectOptions)
+	if !ok {
+		return fmt.Errorf("field %s in %s is not a select field", fieldName, collectionName)
+	}
+
+	for _, value := range values {
+		if !slices.Contains(options.Values, value) {
+			options.Values = append(options.Values, value)
+		}
+	}
+
+	return dao.SaveCollection(collection)
+}
+
+func createCollection(db dbx.Builder, collectionName string, fields ...*schema.SchemaField) error {
+	dao := daos.New(db)
+	if _, err := dao.FindCollectionByNameOrId(collectionName); err == nil {
+		return nil
+	}
+
+	collection := &models.Collection{
+		Name:   collectionName,
+		Type:   models.CollectionTypeBase,
+		Schema: schema.NewSchema(fields...),
+	}
+
+	return dao.SaveCollection(collection)
+}
+
+func deleteCollection(db dbx.Builder, collectionName string) error {
+	dao := daos.New(db)
+	collection, err := dao.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		return nil
+	}
+
+	return dao.DeleteCollection(collection)
+}
+
+func jsonField(name string) *schema.SchemaField {
+	return &schema.SchemaField{Name: name, Type: schema.FieldTypeJson, Options: &schema.JsonOptions{MaxSize: jsonMaxSize}}
+}
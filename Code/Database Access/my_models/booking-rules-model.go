@@ -0,0 +1,91 @@
+package my_models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BookingRules are the stay constraints an owner sets on a property.
+// Zero values disable a rule and an empty CheckInDays allows any weekday.
+type BookingRules struct {
+	MinNights         int   `json:"minNights"`
+	MaxNights         int   `json:"maxNights"`
+	CheckInDays       []int `json:"checkInDays"`
+	AdvanceNoticeDays int   `json:"advanceNoticeDays"`
+	BookingWindowDays int   `json:"bookingWindowDays"`
+}
+
+func (r BookingRules) Validate() error {
+	if r.MinNights < 0 || r.MaxNights < 0 || r.AdvanceNoticeDays < 0 || r.BookingWindowDays < 0 {
+		return fmt.Errorf("booking rules must not be negative")
+	}
+
+	if r.MaxNights > 0 && r.MinNights > r.MaxNights {
+		return fmt.Errorf("minNights must not be greater than maxNights")
+	}
+
+	if r.BookingWindowDays > 0 && r.AdvanceNoticeDays > r.BookingWindowDays {
+		return fmt.Errorf("advanceNoticeDays must not be greater than bookingWindowDays")
+	}
+
+	for _, weekday := range r.CheckInDays {
+		if weekday < 0 || weekday > 6 {
+			return fmt.Errorf("checkInDays must be between 0 (Sunday) and 6 (Saturday)")
+		}
+	}
+
+	return nil
+}
+
+// Check returns a descriptive error for the first rule a stay from fromDate to
+// untilDate, booked on the day today, does not meet.
+func (r BookingRules) Check(fromDate time.Time, untilDate time.Time, today time.Time) error {
+	nights := DaysBetween(fromDate, untilDate)
+	if r.MinNights > 0 && nights < r.MinNights {
+		return fmt.Errorf("this property requires a minimum stay of %d nights, requested %d", r.MinNights, nights)
+	}
+
+	if r.MaxNights > 0 && nights > r.MaxNights {
+		return fmt.Errorf("this property allows a maximum stay of %d nights, requested %d", r.MaxNights, nights)
+	}
+
+	if len(r.CheckInDays) > 0 && !r.allowsCheckInOn(fromDate.Weekday()) {
+		return fmt.Errorf("check-in is not allowed on %s, allowed days are %s", fromDate.Weekday(), r.checkInDayNames())
+	}
+
+	daysUntilCheckIn := DaysBetween(today, fromDate)
+	if daysUntilCheckIn < r.AdvanceNoticeDays {
+		return fmt.Errorf("this property must be booked at least %d days in advance", r.AdvanceNoticeDays)
+	}
+
+	if r.BookingWindowDays > 0 && daysUntilCheckIn > r.BookingWindowDays {
+		return fmt.Errorf("this property can only be booked up to %d days in advance", r.BookingWindowDays)
+	}
+
+	return nil
+}
+
+func (r BookingRules) allowsCheckInOn(weekday time.Weekday) bool {
+	for _, day := range r.CheckInDays {
+		if day == int(weekday) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r BookingRules) checkInDayNames() string {
+	names := make([]string, 0, len(r.CheckInDays))
+	for _, day := range r.CheckInDays {
+		names = append(names, time.Weekday(day).String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// DaysBetween counts the calendar days from one date to another, ignoring the time of day.
+func DaysBetween(from time.Time, until time.Time) int {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+	return int(until.Sub(from).Hours() / 24)
+}
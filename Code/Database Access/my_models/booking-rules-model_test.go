@@ -0,0 +1,63 @@
+package my_models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBookingRulesCheck(t *testing.T) {
+	stay := BookingRules{MinNights: 2, MaxNights: 7}
+	weekends := BookingRules{CheckInDays: []int{int(time.Friday), int(time.Saturday)}}
+	notice := BookingRules{AdvanceNoticeDays: 3}
+	window := BookingRules{BookingWindowDays: 30}
+
+	cases := []struct {
+		name    string
+		rules   BookingRules
+		from    string
+		until   string
+		allowed bool
+	}{
+		{"no rules", BookingRules{}, "2028-03-01", "2028-05-01", true},
+		{"minimum nights", stay, "2027-03-05", "2027-03-07", true},
+		{"below minimum nights", stay, "2027-03-05", "2027-03-06", false},
+		{"maximum nights", stay, "2027-03-05", "2027-03-12", true},
+		{"above maximum nights", stay, "2027-03-05", "2027-03-13", false},
+		{"friday check-in", weekends, "2027-03-05", "2027-03-07", true},
+		{"saturday check-in", weekends, "2027-03-06", "2027-03-07", true},
+		{"sunday check-in", weekends, "2027-03-07", "2027-03-09", false},
+		{"thursday check-in", weekends, "2027-03-04", "2027-03-06", false},
+		{"same day without notice", BookingRules{}, "2027-03-01", "2027-03-02", true},
+		{"exact advance notice", notice, "2027-03-04", "2027-03-05", true},
+		{"short advance notice", notice, "2027-03-03", "2027-03-05", false},
+		{"last day of the window", window, "2027-03-31", "2027-04-02", true},
+		{"beyond the window", window, "2027-04-01", "2027-04-03", false},
+	}
+	// Booked late in the day, only calendar days count
+	today := night("2027-03-01").Add(22 * time.Hour)
+	for _, c := range cases {
+		err := c.rules.Check(night(c.from), night(c.until), today)
+		if (err == nil) != c.allowed {
+			t.Errorf("%s: expected allowed %v, got %v", c.name, c.allowed, err)
+		}
+	}
+}
+
+func TestBookingRulesValidate(t *testing.T) {
+	valid := BookingRules{MinNights: 2, MaxNights: 2, CheckInDays: []int{0, 6}, AdvanceNoticeDays: 30, BookingWindowDays: 30}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected rules to be valid, got %v", err)
+	}
+
+	invalid := map[string]BookingRules{
+		"negative nights":          {MinNights: -1},
+		"minimum above maximum":    {MinNights: 8, MaxNights: 7},
+		"notice beyond the window": {AdvanceNoticeDays: 31, BookingWindowDays: 30},
+		"unknown weekday":          {CheckInDays: []int{7}},
+	}
+	for name, rules := range invalid {
+		if err := rules.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
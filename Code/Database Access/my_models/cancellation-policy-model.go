@@ -0,0 +1,102 @@
+package my_models
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	FlexibleCancellationPolicy = "Flexible"
+	ModerateCancellationPolicy = "Moderate"
+	StrictCancellationPolicy   = "Strict"
+	CustomCancellationPolicy   = "Custom"
+	// CountryCancellationPolicy is used for properties without a policy of their own,
+	// it is built from the country cancellation settings.
+	CountryCancellationPolicy = "Country"
+)
+
+var presetRefundBrackets = map[string][]RefundBracket{
+	FlexibleCancellationPolicy: {{DaysBeforeCheckIn: 1, RefundPercentage: 100}},
+	ModerateCancellationPolicy: {{DaysBeforeCheckIn: 5, RefundPercentage: 100}, {DaysBeforeCheckIn: 0, RefundPercentage: 50}},
+	StrictCancellationPolicy:   {{DaysBeforeCheckIn: 14, RefundPercentage: 100}, {DaysBeforeCheckIn: 7, RefundPercentage: 50}},
+}
+
+// RefundBracket refunds RefundPercentage when the reservation is cancelled at
+// least DaysBeforeCheckIn days before the stay starts.
+type RefundBracket struct {
+	DaysBeforeCheckIn int     `json:"daysBeforeCheckIn"`
+	RefundPercentage  float64 `json:"refundPercentage"`
+}
+
+type CancellationPolicy struct {
+	Type     string          `json:"type"`
+	Brackets []RefundBracket `json:"brackets"`
+}
+
+// NewCountryCancellationPolicy gives a full refund up to fullRefundDays before
+// the stay starts and lateRefundPercentage after that.
+func NewCountryCancellationPolicy(fullRefundDays int, lateRefundPercentage float64) CancellationPolicy {
+	return CancellationPolicy{
+		Type: CountryCancellationPolicy,
+		Brackets: []RefundBracket{
+			{DaysBeforeCheckIn: fullRefundDays, RefundPercentage: 100},
+			{DaysBeforeCheckIn: 0, RefundPercentage: lateRefundPercentage},
+		},
+	}
+}
+
+// Normalize fills in the brackets of the preset policies and validates custom
+// ones. Brackets end up sorted from the earliest to the latest cancellation.
+func (p *CancellationPolicy) Normalize() error {
+	switch p.Type {
+	case FlexibleCancellationPolicy, ModerateCancellationPolicy, StrictCancellationPolicy:
+		p.Brackets = append([]RefundBracket(nil), presetRefundBrackets[p.Type]...)
+	case CustomCancellationPolicy:
+		if len(p.Brackets) == 0 {
+			return fmt.Errorf("custom cancellation policies need at least one bracket")
+		}
+		days := map[int]bool{}
+		for _, bracket := range p.Brackets {
+			if bracket.DaysBeforeCheckIn < 0 {
+				return fmt.Errorf("daysBeforeCheckIn must not be negative")
+			}
+			if bracket.RefundPercentage < 0 || bracket.RefundPercentage > 100 {
+				return fmt.Errorf("refundPercentage must be between 0 and 100")
+			}
+			if days[bracket.DaysBeforeCheckIn] {
+				return fmt.Errorf("there is more than one bracket for %d days before check-in", bracket.DaysBeforeCheckIn)
+			}
+			days[bracket.DaysBeforeCheckIn] = true
+		}
+	default:
+		return fmt.Errorf("invalid cancellation policy %s, valid policies are %s, %s, %s and %s", p.Type, FlexibleCancellationPolicy, ModerateCancellationPolicy, StrictCancellationPolicy, CustomCancellationPolicy)
+	}
+
+	sort.Slice(p.Brackets, func(i, j int) bool {
+		return p.Brackets[i].DaysBeforeCheckIn > p.Brackets[j].DaysBeforeCheckIn
+	})
+	return nil
+}
+
+// RefundPercentage returns the refund of the first bracket whose deadline has
+// not passed yet, or 0 when the cancellation is later than every bracket.
+func (p CancellationPolicy) RefundPercentage(hoursUntilStart float64) float64 {
+	refund := 0.0
+	earliestDays := -1
+	for _, bracket := range p.Brackets {
+		if hoursUntilStart < float64(bracket.DaysBeforeCheckIn*24) {
+			continue
+		}
+		if bracket.DaysBeforeCheckIn > earliestDays {
+			earliestDays = bracket.DaysBeforeCheckIn
+			refund = bracket.RefundPercentage
+		}
+	}
+	return refund
+}
+
+// NoShowRefundPercentage is the refund of a tenant who never checked in. A
+// no-show counts as a cancellation made right when the stay starts.
+func (p CancellationPolicy) NoShowRefundPercentage() float64 {
+	return p.RefundPercentage(0)
+}
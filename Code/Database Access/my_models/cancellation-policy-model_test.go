@@ -0,0 +1,99 @@
+package my_models
+
+import "testing"
+
+func TestCancellationPolicyNormalize(t *testing.T) {
+	strict := CancellationPolicy{Type: StrictCancellationPolicy, Brackets: []RefundBracket{{DaysBeforeCheckIn: 0, RefundPercentage: 100}}}
+	if err := strict.Normalize(); err != nil {
+		t.Fatalf("expected strict policy to be valid, got %v", err)
+	}
+	if len(strict.Brackets) != 2 || strict.Brackets[0].DaysBeforeCheckIn != 14 {
+		t.Errorf("expected preset brackets to replace the given ones, got %v", strict.Brackets)
+	}
+
+	custom := CancellationPolicy{Type: CustomCancellationPolicy, Brackets: []RefundBracket{
+		{DaysBeforeCheckIn: 7, RefundPercentage: 50},
+		{DaysBeforeCheckIn: 30, RefundPercentage: 100},
+		{DaysBeforeCheckIn: 14, RefundPercentage: 75},
+	}}
+	if err := custom.Normalize(); err != nil {
+		t.Fatalf("expected custom policy to be valid, got %v", err)
+	}
+	for i, days := range []int{30, 14, 7} {
+		if custom.Brackets[i].DaysBeforeCheckIn != days {
+			t.Errorf("expected brackets sorted from the earliest cancellation, got %v", custom.Brackets)
+			break
+		}
+	}
+
+	invalid := map[string]CancellationPolicy{
+		"unknown type":       {Type: "Lenient"},
+		"custom without any": {Type: CustomCancellationPolicy},
+		"negative days":      {Type: CustomCancellationPolicy, Brackets: []RefundBracket{{DaysBeforeCheckIn: -1, RefundPercentage: 50}}},
+		"over 100 percent":   {Type: CustomCancellationPolicy, Brackets: []RefundBracket{{DaysBeforeCheckIn: 1, RefundPercentage: 120}}},
+		"repeated days": {Type: CustomCancellationPolicy, Brackets: []RefundBracket{
+			{DaysBeforeCheckIn: 7, RefundPercentage: 50},
+			{DaysBeforeCheckIn: 7, RefundPercentage: 100},
+		}},
+	}
+	for name, policy := range invalid {
+		if err := policy.Normalize(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCancellationPolicyRefundPercentage(t *testing.T) {
+	policy := func(policyType string) CancellationPolicy {
+		p := CancellationPolicy{Type: policyType}
+		p.Normalize()
+		return p
+	}
+
+	cases := []struct {
+		name            string
+		policy          CancellationPolicy
+		hoursUntilStart float64
+		refund          float64
+	}{
+		{"flexible a day before", policy(FlexibleCancellationPolicy), 24, 100},
+		{"flexible same day", policy(FlexibleCancellationPolicy), 23, 0},
+		{"moderate five days before", policy(ModerateCancellationPolicy), 5 * 24, 100},
+		{"moderate a day before", policy(ModerateCancellationPolicy), 24, 50},
+		{"moderate at check in", policy(ModerateCancellationPolicy), 0, 50},
+		{"moderate after check in", policy(ModerateCancellationPolicy), -1, 0},
+		{"strict two weeks before", policy(StrictCancellationPolicy), 14 * 24, 100},
+		{"strict ten days before", policy(StrictCancellationPolicy), 10 * 24, 50},
+		{"strict six days before", policy(StrictCancellationPolicy), 6 * 24, 0},
+		{"country before cutoff", NewCountryCancellationPolicy(7, 30), 8 * 24, 100},
+		{"country after cutoff", NewCountryCancellationPolicy(7, 30), 2 * 24, 30},
+		{"country after check in", NewCountryCancellationPolicy(7, 30), -48, 0},
+	}
+	for _, c := range cases {
+		if refund := c.policy.RefundPercentage(c.hoursUntilStart); refund != c.refund {
+			t.Errorf("%s: expected %v, got %v", c.name, c.refund, refund)
+		}
+	}
+}
+
+func TestCancellationPolicyNoShowRefundPercentage(t *testing.T) {
+	cases := map[string]struct {
+		policy CancellationPolicy
+		refund float64
+	}{
+		"flexible": {CancellationPolicy{Type: FlexibleCancellationPolicy}, 0},
+		"moderate": {CancellationPolicy{Type: ModerateCancellationPolicy}, 50},
+		"strict":   {CancellationPolicy{Type: StrictCancellationPolicy}, 0},
+		"country":  {NewCountryCancellationPolicy(7, 30), 30},
+		"custom": {CancellationPolicy{Type: CustomCancellationPolicy, Brackets: []RefundBracket{
+			{DaysBeforeCheckIn: 3, RefundPercentage: 100},
+			{DaysBeforeCheckIn: 0, RefundPercentage: 25},
+		}}, 25},
+	}
+	for name, c := range cases {
+		c.policy.Normalize()
+		if refund := c.policy.NoShowRefundPercentage(); refund != c.refund {
+			t.Errorf("%s: expected %v, got %v", name, c.refund, refund)
+		}
+	}
+}
@@ -1,8 +0,0 @@
-package my_models
-
-type CardInformation struct {
-	CardNumber string `json:"cardNumber"`
-	Name       string `json:"name"`
-	CVV        string `json:"cvv"`
-	ExpDate    string `json:"expDate"`
-}
\ No newline at end of file
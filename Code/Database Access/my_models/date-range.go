@@ -1,8 +1,19 @@
 package my_models
 
+import "time"
+
 type DateRange struct {
 	Start string `json:"start" db:"start"`
 	End   string `json:"end" db:"end"`
 }
 
 const PocketTimeLayout = "2006-01-02 15:04:05.000Z"
+
+// ParseReservationDate accepts both the date only format used in requests and
+// the datetime format pocketbase stores reservation dates with.
+func ParseReservationDate(date string) (time.Time, error) {
+	if parsed, err := time.Parse(PocketTimeLayout, date); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.DateOnly, date)
+}
@@ -0,0 +1,163 @@
+package my_models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	PassportDocument   = "Passport"
+	NationalIdDocument = "NationalId"
+	adultAge           = 18
+)
+
+var passportFormat = regexp.MustCompile(`^[A-Z0-9]{6,9}$`)
+
+// nationalIdFormats are the national identity document formats of the countries
+// we operate in, keyed by ISO 3166-1 alpha-2 code. Other countries only get a
+// generic sanity check.
+var nationalIdFormats = map[string]*regexp.Regexp{
+	"UY": regexp.MustCompile(`^\d{7,8}$`),
+	"AR": regexp.MustCompile(`^\d{7,8}$`),
+	"BR": regexp.MustCompile(`^\d{11}$`),
+	"CL": regexp.MustCompile(`^\d{7,8}[0-9K]$`),
+	"PY": regexp.MustCompile(`^\d{5,8}$`),
+}
+
+var genericNationalIdFormat = regexp.MustCompile(`^[A-Z0-9]{4,20}$`)
+
+type Guest struct {
+	Id             string `json:"id" db:"id"`
+	ReservationId  string `json:"reservationId" db:"reservationId"`
+	Name           string `json:"name" db:"name"`
+	LastName       string `json:"lastName" db:"lastName"`
+	DocumentType   string `json:"documentType" db:"documentType"`
+	DocumentNumber string `json:"documentNumber" db:"documentNumber"`
+	BirthDate      string `json:"birthDate" db:"birthDate"`
+	Nationality    string `json:"nationality" db:"nationality"`
+}
+
+type GuestDBO struct {
+	Id             string `json:"id" db:"id"`
+	ReservationId  string `json:"reservationId" db:"reservationId"`
+	Name           string `json:"name" db:"name"`
+	LastName       string `json:"lastName" db:"lastName"`
+	DocumentType   string `json:"documentType" db:"documentType"`
+	DocumentNumber string `json:"documentNumber" db:"documentNumber"`
+	BirthDate      string `json:"birthDate" db:"birthDate"`
+	Nationality    string `json:"nationality" db:"nationality"`
+}
+
+func (d *GuestDBO) ToObject() Guest {
+	return Guest{
+		Id:             d.Id,
+		ReservationId:  d.ReservationId,
+		Name:           d.Name,
+		LastName:       d.LastName,
+		DocumentType:   d.DocumentType,
+		DocumentNumber: d.DocumentNumber,
+		BirthDate:      strings.Split(d.BirthDate, " ")[0],
+		Nationality:    d.Nationality,
+	}
+}
+
+func (g *Guest) ToMap(reservationId string) map[string]interface{} {
+	return map[string]interface{}{
+		"reservationId":  reservationId,
+		"name":           g.Name,
+		"lastName":       g.LastName,
+		"documentType":   g.DocumentType,
+		"documentNumber": g.DocumentNumber,
+		"birthDate":      g.BirthDate,
+		"nationality":    g.Nationality,
+	}
+}
+
+// Validate checks the guest data and that the document number matches the
+// format of the document type in the guest nationality. Document numbers and
+// nationality codes are normalized to upper case without separators.
+func (g *Guest) Validate() error {
+	if strings.TrimSpace(g.Name) == "" || strings.TrimSpace(g.LastName) == "" {
+		return fmt.Errorf("guest name and lastName must be provided")
+	}
+
+	g.Nationality = strings.ToUpper(strings.TrimSpace(g.Nationality))
+	if len(g.Nationality) != 2 {
+		return fmt.Errorf("guest nationality must be a two letter country code")
+	}
+
+	birthDate, err := time.Parse(time.DateOnly, g.BirthDate)
+	if err != nil {
+		return fmt.Errorf("invalid birthDate format, expected YYYY-MM-DD")
+	}
+	if birthDate.After(time.Now()) {
+		return fmt.Errorf("birthDate must not be in the future")
+	}
+
+	g.DocumentNumber = strings.ToUpper(strings.NewReplacer(".", "", "-", "", " ", "").Replace(g.DocumentNumber))
+	var format *regexp.Regexp
+	switch g.DocumentType {
+	case PassportDocument:
+		format = passportFormat
+	case NationalIdDocument:
+		format = genericNationalIdFormat
+		if countryFormat, ok := nationalIdFormats[g.Nationality]; ok {
+			format = countryFormat
+		}
+	default:
+		return fmt.Errorf("invalid document type %s, valid types are %s and %s", g.DocumentType, PassportDocument, NationalIdDocument)
+	}
+
+	if !format.MatchString(g.DocumentNumber) {
+		return fmt.Errorf("document number %s is not a valid %s %s", g.DocumentNumber, g.Nationality, g.DocumentType)
+	}
+
+	return nil
+}
+
+// IsAdult reports whether the guest is of age on the given date.
+func (g *Guest) IsAdult(on time.Time) bool {
+	birthDate, err := time.Parse(time.DateOnly, g.BirthDate)
+	if err != nil {
+		return false
+	}
+	return !birthDate.AddDate(adultAge, 0, 0).After(on)
+}
+
+// CheckGuestRoster validates the guests against the adults and minors of the
+// reservation, using their age on the check in date. An incomplete roster is
+// only an error when requireComplete is set.
+func CheckGuestRoster(guests []Guest, reservation ReservationModel, requireComplete bool) error {
+	checkInDate, err := ParseReservationDate(reservation.ReservedFrom)
+	if err != nil {
+		return err
+	}
+
+	adults, minors := 0, 0
+	documents := map[string]bool{}
+	for _, guest := range guests {
+		key := guest.Nationality + guest.DocumentType + guest.DocumentNumber
+		if documents[key] {
+			return fmt.Errorf("document %s is used by more than one guest", guest.DocumentNumber)
+		}
+		documents[key] = true
+
+		if guest.IsAdult(checkInDate) {
+			adults++
+		} else {
+			minors++
+		}
+	}
+
+	if adults > reservation.Adults || minors > reservation.Minors {
+		return fmt.Errorf("the roster has %d adults and %d minors but the reservation is for %d adults and %d minors", adults, minors, reservation.Adults, reservation.Minors)
+	}
+
+	if requireComplete && (adults < reservation.Adults || minors < reservation.Minors) {
+		return fmt.Errorf("the guest roster is incomplete, %d adults and %d minors are missing", reservation.Adults-adults, reservation.Minors-minors)
+	}
+
+	return nil
+}
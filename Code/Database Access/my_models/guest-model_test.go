@@ -0,0 +1,80 @@
+package my_models
+
+import "testing"
+
+func guest(documentType string, documentNumber string, nationality string, birthDate string) Guest {
+	return Guest{
+		Name:           "Ana",
+		LastName:       "Perez",
+		DocumentType:   documentType,
+		DocumentNumber: documentNumber,
+		Nationality:    nationality,
+		BirthDate:      birthDate,
+	}
+}
+
+func TestGuestValidateDocuments(t *testing.T) {
+	cases := []struct {
+		name  string
+		guest Guest
+		valid bool
+	}{
+		{"uruguayan id with separators", guest(NationalIdDocument, "1.234.567-8", "uy", "1990-01-01"), true},
+		{"uruguayan id too short", guest(NationalIdDocument, "12345", "UY", "1990-01-01"), false},
+		{"argentinian id", guest(NationalIdDocument, "30123456", "AR", "1990-01-01"), true},
+		{"brazilian cpf", guest(NationalIdDocument, "123.456.789-01", "BR", "1990-01-01"), true},
+		{"brazilian cpf too short", guest(NationalIdDocument, "1234567890", "BR", "1990-01-01"), false},
+		{"chilean rut with K", guest(NationalIdDocument, "12.345.678-k", "CL", "1990-01-01"), true},
+		{"chilean rut with letter", guest(NationalIdDocument, "12345678-X", "CL", "1990-01-01"), false},
+		{"paraguayan id", guest(NationalIdDocument, "123456", "PY", "1990-01-01"), true},
+		{"other country id", guest(NationalIdDocument, "X1234567", "ES", "1990-01-01"), true},
+		{"other country id with symbols", guest(NationalIdDocument, "X12#4567", "ES", "1990-01-01"), false},
+		{"passport", guest(PassportDocument, "ab123456", "US", "1990-01-01"), true},
+		{"passport too long", guest(PassportDocument, "AB12345678", "US", "1990-01-01"), false},
+		{"unknown document type", guest("DriverLicense", "12345678", "UY", "1990-01-01"), false},
+		{"three letter nationality", guest(PassportDocument, "AB123456", "URY", "1990-01-01"), false},
+		{"future birth date", guest(PassportDocument, "AB123456", "UY", "2999-01-01"), false},
+		{"bad birth date", guest(PassportDocument, "AB123456", "UY", "01/01/1990"), false},
+		{"no name", Guest{LastName: "Perez", DocumentType: PassportDocument, DocumentNumber: "AB123456", Nationality: "UY", BirthDate: "1990-01-01"}, false},
+	}
+	for _, c := range cases {
+		err := c.guest.Validate()
+		if c.valid && err != nil {
+			t.Errorf("%s: expected valid guest, got %v", c.name, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
+
+func TestCheckGuestRoster(t *testing.T) {
+	reservation := ReservationModel{ReservedFrom: "2027-03-01 00:00:00.000Z", Adults: 2, Minors: 1}
+	adult := guest(NationalIdDocument, "12345678", "UY", "1990-01-01")
+	otherAdult := guest(NationalIdDocument, "87654321", "UY", "1985-06-15")
+	// Turns 18 the day after check in, so still a minor for this stay
+	minor := guest(NationalIdDocument, "11111111", "UY", "2009-03-02")
+
+	cases := []struct {
+		name            string
+		guests          []Guest
+		requireComplete bool
+		valid           bool
+	}{
+		{"complete roster", []Guest{adult, otherAdult, minor}, true, true},
+		{"partial roster before check in", []Guest{adult}, false, true},
+		{"partial roster at check in", []Guest{adult, otherAdult}, true, false},
+		{"too many adults", []Guest{adult, otherAdult, guest(PassportDocument, "AB123456", "US", "1970-01-01")}, false, false},
+		{"too many minors", []Guest{minor, guest(NationalIdDocument, "22222222", "UY", "2015-01-01")}, false, false},
+		{"repeated document", []Guest{adult, adult}, false, false},
+	}
+	for _, c := range cases {
+		err := CheckGuestRoster(c.guests, reservation, c.requireComplete)
+		if c.valid && err != nil {
+			t.Errorf("%s: expected valid roster, got %v", c.name, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package my_models
+
+// HostCancellation records a reservation cancelled by the owner of the
+// property, or by an Admin on its behalf, and the penalty charged to the owner.
+type HostCancellation struct {
+	Id            string  `json:"id" db:"id"`
+	ReservationId string  `json:"reservationId" db:"reservationId"`
+	PropertyId    string  `json:"propertyId" db:"propertyId"`
+	Owner         string  `json:"owner" db:"owner"`
+	CancelledBy   string  `json:"cancelledBy" db:"cancelledBy"`
+	Reason        string  `json:"reason" db:"reason"`
+	Penalty       float64 `json:"penalty" db:"penalty"`
+	Created       string  `json:"created" db:"created"`
+}
+
+func (c *HostCancellation) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"reservationId": c.ReservationId,
+		"propertyId":    c.PropertyId,
+		"owner":         c.Owner,
+		"cancelledBy":   c.CancelledBy,
+		"reason":        c.Reason,
+		"penalty":       c.Penalty,
+	}
+}
+
+type HostCancellationsReportItem struct {
+	Owner         string  `json:"owner" db:"owner"`
+	OwnerEmail    string  `json:"owner_email" db:"owner_email"`
+	Cancellations int     `json:"cancellations" db:"cancellations"`
+	TotalPenalty  float64 `json:"total_penalty" db:"total_penalty"`
+}
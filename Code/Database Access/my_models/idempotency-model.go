@@ -0,0 +1,37 @@
+package my_models
+
+import "errors"
+
+const (
+	IdempotencyInProgress = "InProgress"
+	IdempotencyCompleted  = "Completed"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("Idempotency-Key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this Idempotency-Key is still being processed")
+)
+
+// IdempotencyRecord is what is kept for an Idempotency-Key: the hash of the
+// request that used it first and, once it finished, the response to replay.
+type IdempotencyRecord struct {
+	Key         string `json:"key" db:"key"`
+	RequestHash string `json:"requestHash" db:"requestHash"`
+	Status      string `json:"status" db:"status"`
+	StatusCode  int    `json:"statusCode" db:"statusCode"`
+	ContentType string `json:"contentType" db:"contentType"`
+	Body        string `json:"body" db:"body"`
+	ExpiresAt   string `json:"expiresAt" db:"expiresAt"`
+}
+
+func (r *IdempotencyRecord) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"key":         r.Key,
+		"requestHash": r.RequestHash,
+		"status":      r.Status,
+		"statusCode":  r.StatusCode,
+		"contentType": r.ContentType,
+		"body":        r.Body,
+		"expiresAt":   r.ExpiresAt,
+	}
+}
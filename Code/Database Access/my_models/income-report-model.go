@@ -7,6 +7,11 @@ type IncomeReport struct {
 	Country         string                `json:"country"`
 	City            string                `json:"city"`
 	TotalIncome     float64               `json:"total_income"`
+	TotalCommission float64               `json:"total_commission"`
+	TotalFees       float64               `json:"total_fees"`
+	TotalNet        float64               `json:"total_net"`
+	TotalCollected  float64               `json:"total_collected"`
+	TotalPending    float64               `json:"total_pending"`
 	FromDate        time.Time             `json:"from_date"`
 	ToDate          time.Time             `json:"to_date"`
 	BookingsReports []BookingIncomeReport `json:"bookings_reports"`
@@ -15,6 +20,12 @@ type IncomeReport struct {
 type BookingIncomeReport struct {
 	BookingId      string    `json:"booking_id" db:"booking_id"`
 	Income         float64   `json:"income" db:"income"`
+	Commission     float64   `json:"commission" db:"commission"`
+	Fees           float64   `json:"fees" db:"fees"`
+	Net            float64   `json:"net" db:"net"`
+	Status         string    `json:"status" db:"status"`
+	AmountPaid     float64   `json:"amount_paid" db:"amount_paid"`
+	AmountPending  float64   `json:"amount_pending" db:"amount_pending"`
 	FromDate       time.Time `json:"from_date" db:"from_date"`
 	ToDate         time.Time `json:"to_date" db:"to_date"`
 	TenantEmail    string    `json:"tenant_email" db:"tenant_email"`
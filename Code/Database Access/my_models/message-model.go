@@ -0,0 +1,98 @@
+package my_models
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	TenantSender          = "Tenant"
+	OwnerSender           = "Owner"
+	maxMessageLength      = 5000
+	MaxMessageAttachments = 5
+)
+
+// Message is one entry of the conversation between the tenant and the owner
+// of a reservation. ReadAt is set once the other party reads it.
+type Message struct {
+	Id            string   `json:"id" db:"id"`
+	ReservationId string   `json:"reservationId" db:"reservationId"`
+	SenderId      string   `json:"senderId" db:"senderId"`
+	SenderRole    string   `json:"senderRole" db:"senderRole"`
+	Body          string   `json:"body" db:"body"`
+	Attachments   []string `json:"attachments" db:"attachments"`
+	ReadAt        string   `json:"readAt" db:"readAt"`
+	Created       string   `json:"created" db:"created"`
+}
+
+// MessageAttachment is an image uploaded with a message before it is stored.
+type MessageAttachment struct {
+	File      multipart.File
+	Extension string
+}
+
+type MessageDBO struct {
+	Id            string        `json:"id" db:"id"`
+	ReservationId string        `json:"reservationId" db:"reservationId"`
+	SenderId      string        `json:"senderId" db:"senderId"`
+	SenderRole    string        `json:"senderRole" db:"senderRole"`
+	Body          string        `json:"body" db:"body"`
+	Attachments   types.JsonRaw `json:"attachments" db:"attachments"`
+	ReadAt        string        `json:"readAt" db:"readAt"`
+	Created       string        `json:"created" db:"created"`
+}
+
+func (d *MessageDBO) ToObject() Message {
+	attachments := []string{}
+	if len(d.Attachments) > 0 {
+		json.Unmarshal(d.Attachments, &attachments)
+	}
+
+	return Message{
+		Id:            d.Id,
+		ReservationId: d.ReservationId,
+		SenderId:      d.SenderId,
+		SenderRole:    d.SenderRole,
+		Body:          d.Body,
+		Attachments:   attachments,
+		ReadAt:        d.ReadAt,
+		Created:       d.Created,
+	}
+}
+
+func (m *Message) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"reservationId": m.ReservationId,
+		"senderId":      m.SenderId,
+		"senderRole":    m.SenderRole,
+		"body":          m.Body,
+		"attachments":   m.Attachments,
+	}
+}
+
+// Validate trims the body and checks the message is not empty. Attachments are
+// counted before they are stored, so the caller passes how many were uploaded.
+func (m *Message) Validate(attachmentCount int) error {
+	m.Body = strings.TrimSpace(m.Body)
+	if m.Body == "" && attachmentCount == 0 {
+		return fmt.Errorf("message must have a body or an attachment")
+	}
+
+	if len(m.Body) > maxMessageLength {
+		return fmt.Errorf("message must not be longer than %d characters", maxMessageLength)
+	}
+
+	if attachmentCount > MaxMessageAttachments {
+		return fmt.Errorf("message must not have more than %d attachments", MaxMessageAttachments)
+	}
+
+	if m.SenderRole != TenantSender && m.SenderRole != OwnerSender {
+		return fmt.Errorf("invalid sender role %s, valid roles are %s and %s", m.SenderRole, TenantSender, OwnerSender)
+	}
+
+	return nil
+}
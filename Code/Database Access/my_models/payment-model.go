@@ -0,0 +1,144 @@
+package my_models
+
+import "fmt"
+
+const (
+	ChargePayment = "Charge"
+	RefundPayment = "Refund"
+
+	PaymentPending   = "Pending"
+	PaymentSucceeded = "Succeeded"
+	PaymentFailed    = "Failed"
+)
+
+// Payment is a row of the payments ledger: one charge or refund attempt sent
+// to the payment gateway for a reservation or a property. Refunds point to the
+// charge they give money back from.
+type Payment struct {
+	Id               string  `json:"id" db:"id"`
+	ReservationId    string  `json:"reservationId,omitempty" db:"reservationId"`
+	PropertyId       string  `json:"propertyId,omitempty" db:"propertyId"`
+	ChargeId         string  `json:"chargeId,omitempty" db:"chargeId"`
+	Kind             string  `json:"kind" db:"kind"`
+	Amount           float64 `json:"amount" db:"amount"`
+	Currency         string  `json:"currency" db:"currency"`
+	GatewayReference string  `json:"gatewayReference" db:"gatewayReference"`
+	Status           string  `json:"status" db:"status"`
+	FailureReason    string  `json:"failureReason" db:"failureReason"`
+	CardToken        string  `json:"-" db:"cardToken"`
+	Created          string  `json:"created" db:"created"`
+	Updated          string  `json:"updated" db:"updated"`
+}
+
+func (p *Payment) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"reservationId":    p.ReservationId,
+		"propertyId":       p.PropertyId,
+		"chargeId":         p.ChargeId,
+		"kind":             p.Kind,
+		"amount":           p.Amount,
+		"currency":         p.Currency,
+		"gatewayReference": p.GatewayReference,
+		"status":           p.Status,
+		"failureReason":    p.FailureReason,
+		"cardToken":        p.CardToken,
+	}
+}
+
+const (
+	ChargeEvent = "charge"
+	RefundEvent = "refund"
+
+	EventSucceeded = "succeeded"
+	EventFailed    = "failed"
+)
+
+// PaymentEvent is the outcome of a charge or refund the Payment-Module settled
+// in the background, delivered through its webhook. Reference is the one the
+// module answered the original call with.
+type PaymentEvent struct {
+	Id        string  `json:"id"`
+	Type      string  `json:"type"`
+	Reference string  `json:"reference"`
+	Status    string  `json:"status"`
+	Amount    float64 `json:"amount"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+func (e *PaymentEvent) Validate() error {
+	if e.Id == "" || e.Reference == "" {
+		return fmt.Errorf("payment event must have an id and a reference")
+	}
+	if e.Type != ChargeEvent && e.Type != RefundEvent {
+		return fmt.Errorf("unknown payment event type %q", e.Type)
+	}
+	if e.Status != EventSucceeded && e.Status != EventFailed {
+		return fmt.Errorf("unknown payment event status %q", e.Status)
+	}
+	return nil
+}
+
+func (e *PaymentEvent) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"eventId":   e.Id,
+		"type":      e.Type,
+		"reference": e.Reference,
+		"status":    e.Status,
+	}
+}
+
+// PaymentJob is a queued reservation charge.
+type PaymentJob struct {
+	PaymentId     string `json:"paymentId"`
+	ReservationId string `json:"reservationId"`
+	CardToken     string `json:"cardToken"`
+}
+
+// RefundableCharge is a succeeded charge and how much of it can still be refunded.
+type RefundableCharge struct {
+	Charge    Payment
+	Remaining float64
+}
+
+// RefundableCharges matches the refunds of the ledger against their charges,
+// oldest charge first. Pending refunds count as refunded so the same money is
+// never sent back twice.
+func RefundableCharges(payments []Payment) []RefundableCharge {
+	refunded := map[string]float64{}
+	for _, payment := range payments {
+		if payment.Kind == RefundPayment && payment.Status != PaymentFailed {
+			refunded[payment.ChargeId] += payment.Amount
+		}
+	}
+
+	// Refunds recorded before they were linked to a charge are taken from the oldest ones
+	unlinked := refunded[""]
+
+	charges := []RefundableCharge{}
+	for _, payment := range payments {
+		if payment.Kind != ChargePayment || payment.Status != PaymentSucceeded {
+			continue
+		}
+
+		remaining := RoundPrice(payment.Amount - refunded[payment.Id])
+		if unlinked > 0 && remaining > 0 {
+			taken := min(unlinked, remaining)
+			remaining = RoundPrice(remaining - taken)
+			unlinked -= taken
+		}
+		if remaining > 0 {
+			charges = append(charges, RefundableCharge{Charge: payment, Remaining: remaining})
+		}
+	}
+
+	return charges
+}
+
+// TotalRefundable is the money captured that has not been refunded yet.
+func TotalRefundable(charges []RefundableCharge) float64 {
+	total := 0.0
+	for _, charge := range charges {
+		total += charge.Remaining
+	}
+	return RoundPrice(total)
+}
@@ -0,0 +1,129 @@
+package my_models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DepositInstallment = "Deposit"
+	BalanceInstallment = "Balance"
+	FullInstallment    = "Full"
+
+	InstallmentPending   = "Pending"
+	InstallmentPaid      = "Paid"
+	InstallmentFailed    = "Failed"
+	InstallmentCancelled = "Cancelled"
+)
+
+// PaymentTerms let a property take a deposit at booking and charge the balance
+// BalanceDueDays before arrival. A zero DepositPercentage charges the whole stay
+// at once.
+type PaymentTerms struct {
+	DepositPercentage float64 `json:"depositPercentage"`
+	BalanceDueDays    int     `json:"balanceDueDays"`
+}
+
+func (t PaymentTerms) Validate() error {
+	if t.DepositPercentage < 0 || t.DepositPercentage >= 100 {
+		return fmt.Errorf("depositPercentage must be between 0 and 100")
+	}
+
+	if t.DepositPercentage > 0 && t.BalanceDueDays < 1 {
+		return fmt.Errorf("balanceDueDays must be at least 1 when a deposit is required")
+	}
+
+	return nil
+}
+
+// BalanceDueDate is the date the balance of a stay starting on checkIn is charged.
+func (t PaymentTerms) BalanceDueDate(checkIn time.Time) time.Time {
+	return checkIn.AddDate(0, 0, -t.BalanceDueDays)
+}
+
+// SplitsPayment reports whether a stay starting on checkIn is paid in two
+// installments. Stays whose balance would already be due are paid in full.
+func (t PaymentTerms) SplitsPayment(checkIn time.Time, now time.Time) bool {
+	return t.DepositPercentage > 0 && t.BalanceDueDate(checkIn).After(now)
+}
+
+// PaymentInstallment is one charge of the payment schedule of a reservation.
+// The card token of a pending balance is kept so it can be charged later.
+type PaymentInstallment struct {
+	Id            string  `json:"id" db:"id"`
+	ReservationId string  `json:"reservationId" db:"reservationId"`
+	Kind          string  `json:"kind" db:"kind"`
+	Amount        float64 `json:"amount" db:"amount"`
+	DueDate       string  `json:"dueDate" db:"dueDate"`
+	Status        string  `json:"status" db:"status"`
+	PaidAt        string  `json:"paidAt" db:"paidAt"`
+	Attempts      int     `json:"attempts" db:"attempts"`
+	LastError     string  `json:"lastError" db:"lastError"`
+	CardToken     string  `json:"-" db:"-"`
+}
+
+type PaymentInstallmentDBO struct {
+	Id            string  `json:"id" db:"id"`
+	ReservationId string  `json:"reservationId" db:"reservationId"`
+	Kind          string  `json:"kind" db:"kind"`
+	Amount        float64 `json:"amount" db:"amount"`
+	DueDate       string  `json:"dueDate" db:"dueDate"`
+	Status        string  `json:"status" db:"status"`
+	PaidAt        string  `json:"paidAt" db:"paidAt"`
+	Attempts      int     `json:"attempts" db:"attempts"`
+	LastError     string  `json:"lastError" db:"lastError"`
+	CardToken     string  `json:"cardToken" db:"cardToken"`
+}
+
+func (d *PaymentInstallmentDBO) ToObject() PaymentInstallment {
+	return PaymentInstallment{
+		Id:            d.Id,
+		ReservationId: d.ReservationId,
+		Kind:          d.Kind,
+		Amount:        d.Amount,
+		DueDate:       strings.Split(d.DueDate, " ")[0],
+		Status:        d.Status,
+		PaidAt:        d.PaidAt,
+		Attempts:      d.Attempts,
+		LastError:     d.LastError,
+		CardToken:     d.CardToken,
+	}
+}
+
+func (i *PaymentInstallment) ToMap(reservationId string) map[string]interface{} {
+	data := map[string]interface{}{
+		"reservationId": reservationId,
+		"kind":          i.Kind,
+		"amount":        i.Amount,
+		"dueDate":       i.DueDate,
+		"status":        i.Status,
+		"paidAt":        i.PaidAt,
+	}
+
+	if i.Status == InstallmentPending {
+		data["cardToken"] = i.CardToken
+	}
+
+	return data
+}
+
+// AmountPaid adds up the paid installments of a reservation. Reservations paid
+// before payment schedules existed have none, so their whole total counts when
+// they are Paid.
+func AmountPaid(reservation ReservationModel, installments []PaymentInstallment, total float64) float64 {
+	if len(installments) == 0 {
+		if reservation.Status == "Paid" {
+			return total
+		}
+		return 0
+	}
+
+	paid := 0.0
+	for _, installment := range installments {
+		if installment.Status == InstallmentPaid {
+			paid += installment.Amount
+		}
+	}
+	return RoundPrice(paid)
+}
@@ -0,0 +1,111 @@
+package my_models
+
+const (
+	PayoutScheduled = "Scheduled"
+	PayoutBatched   = "Batched"
+	PayoutPaid      = "Paid"
+	PayoutCancelled = "Cancelled"
+
+	BatchProcessing = "Processing"
+	BatchPaid       = "Paid"
+	BatchFailed     = "Failed"
+)
+
+// OwnerEarnings splits what the tenant pays for a stay. The service fee and the
+// taxes are kept by the platform as Fees, and the commission is taken from what
+// the owner charges for the nights and the cleaning.
+type OwnerEarnings struct {
+	Gross      float64 `json:"gross"`
+	Commission float64 `json:"commission"`
+	Fees       float64 `json:"fees"`
+	Net        float64 `json:"net"`
+}
+
+// NewOwnerEarnings splits the amount collected for a stay in the proportions of
+// its quote, so a partly refunded stay keeps the same share for each party.
+func NewOwnerEarnings(quote PriceQuote, collected float64, commissionPercentage float64) OwnerEarnings {
+	share := 0.0
+	if quote.Total > 0 {
+		share = collected / quote.Total
+	}
+	commission := RoundPrice((quote.Subtotal + quote.CleaningFee) * share * commissionPercentage / 100)
+	fees := RoundPrice((quote.ServiceFee + quote.Taxes) * share)
+
+	return OwnerEarnings{
+		Gross:      RoundPrice(collected),
+		Commission: commission,
+		Fees:       fees,
+		Net:        RoundPrice(collected - commission - fees),
+	}
+}
+
+// Payout is what the owner of a property is owed for a stay. It is released
+// once DueAt is reached, grouped with the other due payouts of the owner in a
+// PayoutBatch.
+type Payout struct {
+	Id            string  `json:"id" db:"id"`
+	ReservationId string  `json:"reservationId" db:"reservationId"`
+	PropertyId    string  `json:"propertyId" db:"propertyId"`
+	OwnerId       string  `json:"ownerId" db:"ownerId"`
+	BatchId       string  `json:"batchId,omitempty" db:"batchId"`
+	Gross         float64 `json:"gross" db:"gross"`
+	Commission    float64 `json:"commission" db:"commission"`
+	Fees          float64 `json:"fees" db:"fees"`
+	Net           float64 `json:"net" db:"net"`
+	Status        string  `json:"status" db:"status"`
+	DueAt         string  `json:"dueAt" db:"dueAt"`
+	Created       string  `json:"created" db:"created"`
+	Updated       string  `json:"updated" db:"updated"`
+}
+
+func (p *Payout) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"reservationId": p.ReservationId,
+		"propertyId":    p.PropertyId,
+		"ownerId":       p.OwnerId,
+		"batchId":       p.BatchId,
+		"gross":         p.Gross,
+		"commission":    p.Commission,
+		"fees":          p.Fees,
+		"net":           p.Net,
+		"status":        p.Status,
+		"dueAt":         p.DueAt,
+	}
+}
+
+type PayoutBatch struct {
+	Id          string  `json:"id" db:"id"`
+	OwnerId     string  `json:"ownerId" db:"ownerId"`
+	Status      string  `json:"status" db:"status"`
+	Total       float64 `json:"total" db:"total"`
+	Count       int     `json:"count" db:"count"`
+	ProcessedAt string  `json:"processedAt" db:"processedAt"`
+	Created     string  `json:"created" db:"created"`
+}
+
+// OwnerPayouts is what GET /owner/payouts answers with.
+type OwnerPayouts struct {
+	Payouts   []Payout      `json:"payouts"`
+	Batches   []PayoutBatch `json:"batches"`
+	TotalPaid float64       `json:"totalPaid"`
+	TotalDue  float64       `json:"totalDue"`
+}
+
+func NewOwnerPayouts(payouts []Payout, batches []PayoutBatch) OwnerPayouts {
+	paid, due := 0.0, 0.0
+	for _, payout := range payouts {
+		switch payout.Status {
+		case PayoutPaid:
+			paid += payout.Net
+		case PayoutScheduled, PayoutBatched:
+			due += payout.Net
+		}
+	}
+
+	return OwnerPayouts{
+		Payouts:   payouts,
+		Batches:   batches,
+		TotalPaid: RoundPrice(paid),
+		TotalDue:  RoundPrice(due),
+	}
+}
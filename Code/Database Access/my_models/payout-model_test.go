@@ -0,0 +1,22 @@
+package my_models
+
+import "testing"
+
+func TestNewOwnerEarnings(t *testing.T) {
+	quote := PriceQuote{Subtotal: 300, CleaningFee: 30, ServiceFee: 30, Taxes: 72.6, Total: 432.6}
+
+	cases := []struct {
+		name      string
+		collected float64
+		earnings  OwnerEarnings
+	}{
+		{"paid in full", 432.6, OwnerEarnings{Gross: 432.6, Commission: 9.9, Fees: 102.6, Net: 320.1}},
+		{"half refunded", 216.3, OwnerEarnings{Gross: 216.3, Commission: 4.95, Fees: 51.3, Net: 160.05}},
+		{"fully refunded", 0, OwnerEarnings{}},
+	}
+	for _, c := range cases {
+		if earnings := NewOwnerEarnings(quote, c.collected, 3); earnings != c.earnings {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.earnings, earnings)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package my_models
+
+import (
+	"fmt"
+	"math"
+)
+
+type QuoteRequest struct {
+	PropertyId    string `json:"property"`
+	ReservedFrom  string `json:"reserved_from"`
+	ReservedUntil string `json:"reserved_until"`
+	Country       string `json:"country"`
+}
+
+type NightlyRate struct {
+	Date  string  `json:"date"`
+	Price float64 `json:"price"`
+}
+
+type PriceQuote struct {
+	PropertyId         string             `json:"property"`
+	ReservedFrom       string             `json:"reserved_from"`
+	ReservedUntil      string             `json:"reserved_until"`
+	Nights             int                `json:"nights"`
+	MinNights          int                `json:"min_nights"`
+	NightlyRates       []NightlyRate      `json:"nightly_rates"`
+	Subtotal           float64            `json:"subtotal"`
+	CleaningFee        float64            `json:"cleaning_fee"`
+	ServiceFee         float64            `json:"service_fee"`
+	Taxes              float64            `json:"taxes"`
+	Total              float64            `json:"total"`
+	CancellationPolicy CancellationPolicy `json:"cancellation_policy"`
+}
+
+// CheckMinNights rejects stays shorter than the minimum set by the price rule of
+// the check-in night. It only applies to new bookings, existing reservations are
+// still priced when a rule is added later.
+func (q PriceQuote) CheckMinNights() error {
+	if q.Nights < q.MinNights {
+		return fmt.Errorf("stays starting on %s must be at least %d nights long", q.ReservedFrom, q.MinNights)
+	}
+	return nil
+}
+
+func RoundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
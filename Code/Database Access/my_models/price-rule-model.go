@@ -0,0 +1,173 @@
+package my_models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	SeasonPriceRule     = "Season"
+	WeekendPriceRule    = "Weekend"
+	SpecialDayPriceRule = "SpecialDay"
+)
+
+var defaultWeekendDays = []int{int(time.Friday), int(time.Saturday)}
+
+type PriceRule struct {
+	Id         string  `json:"id" db:"id"`
+	PropertyId string  `json:"propertyId" db:"propertyId"`
+	Type       string  `json:"type" db:"type"`
+	DateFrom   string  `json:"dateFrom" db:"dateFrom"`
+	DateTo     string  `json:"dateTo" db:"dateTo"`
+	Weekdays   []int   `json:"weekdays" db:"weekdays"`
+	Price      float64 `json:"price" db:"price"`
+	MinNights  int     `json:"minNights" db:"minNights"`
+}
+
+type PriceRuleDBO struct {
+	Id         string        `json:"id" db:"id"`
+	PropertyId string        `json:"propertyId" db:"propertyId"`
+	Type       string        `json:"type" db:"type"`
+	DateFrom   string        `json:"dateFrom" db:"dateFrom"`
+	DateTo     string        `json:"dateTo" db:"dateTo"`
+	Weekdays   types.JsonRaw `json:"weekdays" db:"weekdays"`
+	Price      float64       `json:"price" db:"price"`
+	MinNights  int           `json:"minNights" db:"minNights"`
+}
+
+func (d *PriceRuleDBO) ToObject() PriceRule {
+	var weekdays []int
+	if len(d.Weekdays) > 0 {
+		json.Unmarshal(d.Weekdays, &weekdays)
+	}
+
+	return PriceRule{
+		Id:         d.Id,
+		PropertyId: d.PropertyId,
+		Type:       d.Type,
+		DateFrom:   strings.Split(d.DateFrom, " ")[0],
+		DateTo:     strings.Split(d.DateTo, " ")[0],
+		Weekdays:   weekdays,
+		Price:      d.Price,
+		MinNights:  d.MinNights,
+	}
+}
+
+func (r *PriceRule) ToMap(propertyId string) map[string]interface{} {
+	return map[string]interface{}{
+		"propertyId": propertyId,
+		"type":       r.Type,
+		"dateFrom":   r.DateFrom,
+		"dateTo":     r.DateTo,
+		"weekdays":   r.Weekdays,
+		"price":      r.Price,
+		"minNights":  r.MinNights,
+	}
+}
+
+func (r *PriceRule) Validate() error {
+	if r.Price <= 0 {
+		return fmt.Errorf("price must be greater than 0")
+	}
+
+	if r.MinNights < 0 {
+		return fmt.Errorf("minNights must not be negative")
+	}
+
+	switch r.Type {
+	case SeasonPriceRule:
+		if r.DateFrom == "" || r.DateTo == "" {
+			return fmt.Errorf("season rules need both dateFrom and dateTo")
+		}
+	case SpecialDayPriceRule:
+		if r.DateFrom == "" {
+			return fmt.Errorf("special day rules need a dateFrom")
+		}
+		r.DateTo = r.DateFrom
+	case WeekendPriceRule:
+		if len(r.Weekdays) == 0 {
+			r.Weekdays = defaultWeekendDays
+		}
+		for _, weekday := range r.Weekdays {
+			if weekday < 0 || weekday > 6 {
+				return fmt.Errorf("weekdays must be between 0 (Sunday) and 6 (Saturday)")
+			}
+		}
+		if (r.DateFrom == "") != (r.DateTo == "") {
+			return fmt.Errorf("provide both dateFrom and dateTo or none of them")
+		}
+	default:
+		return fmt.Errorf("invalid price rule type %s, valid types are %s, %s and %s", r.Type, SeasonPriceRule, WeekendPriceRule, SpecialDayPriceRule)
+	}
+
+	if r.DateFrom != "" {
+		fromDate, err := time.Parse(time.DateOnly, r.DateFrom)
+		if err != nil {
+			return fmt.Errorf("invalid dateFrom format, expected YYYY-MM-DD")
+		}
+		untilDate, err := time.Parse(time.DateOnly, r.DateTo)
+		if err != nil {
+			return fmt.Errorf("invalid dateTo format, expected YYYY-MM-DD")
+		}
+		if fromDate.After(untilDate) {
+			return fmt.Errorf("dateFrom must not be after dateTo")
+		}
+	}
+
+	return nil
+}
+
+// Applies reports whether the rule covers the given night. Date ranges are inclusive.
+func (r *PriceRule) Applies(night time.Time) bool {
+	if r.DateFrom != "" {
+		date := night.Format(time.DateOnly)
+		if date < r.DateFrom || date > r.DateTo {
+			return false
+		}
+	}
+
+	if r.Type != WeekendPriceRule {
+		return r.DateFrom != ""
+	}
+
+	for _, weekday := range r.Weekdays {
+		if int(night.Weekday()) == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// priority makes the most specific rule win: special days, then weekend rates
+// limited to a date range, then seasons, then plain weekend rates.
+func (r *PriceRule) priority() int {
+	switch {
+	case r.Type == SpecialDayPriceRule:
+		return 4
+	case r.Type == WeekendPriceRule && r.DateFrom != "":
+		return 3
+	case r.Type == SeasonPriceRule:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// ApplicablePriceRule returns the rule that sets the price of the night, or nil
+// when the property base price applies.
+func ApplicablePriceRule(night time.Time, rules []PriceRule) *PriceRule {
+	var applicable *PriceRule
+	for i := range rules {
+		if !rules[i].Applies(night) {
+			continue
+		}
+		if applicable == nil || rules[i].priority() > applicable.priority() {
+			applicable = &rules[i]
+		}
+	}
+	return applicable
+}
@@ -0,0 +1,95 @@
+package my_models
+
+import (
+	"testing"
+	"time"
+)
+
+func night(value string) time.Time {
+	parsed, _ := time.Parse(time.DateOnly, value)
+	return parsed
+}
+
+func TestPriceRuleApplies(t *testing.T) {
+	season := PriceRule{Type: SeasonPriceRule, DateFrom: "2027-01-01", DateTo: "2027-01-31", Price: 150}
+	specialDay := PriceRule{Type: SpecialDayPriceRule, DateFrom: "2027-12-31", DateTo: "2027-12-31", Price: 300}
+	weekend := PriceRule{Type: WeekendPriceRule, Weekdays: defaultWeekendDays, Price: 120}
+	seasonWeekend := PriceRule{Type: WeekendPriceRule, DateFrom: "2027-01-01", DateTo: "2027-01-31", Weekdays: []int{int(time.Saturday)}, Price: 200}
+
+	cases := []struct {
+		name    string
+		rule    PriceRule
+		night   string
+		applies bool
+	}{
+		{"season first night", season, "2027-01-01", true},
+		{"season last night", season, "2027-01-31", true},
+		{"before season", season, "2026-12-31", false},
+		{"after season", season, "2027-02-01", false},
+		{"special day", specialDay, "2027-12-31", true},
+		{"day after special day", specialDay, "2028-01-01", false},
+		{"friday", weekend, "2027-03-05", true},
+		{"saturday", weekend, "2027-03-06", true},
+		{"sunday", weekend, "2027-03-07", false},
+		{"saturday in range", seasonWeekend, "2027-01-02", true},
+		{"friday in range", seasonWeekend, "2027-01-01", false},
+		{"saturday out of range", seasonWeekend, "2027-02-06", false},
+	}
+	for _, c := range cases {
+		if applies := c.rule.Applies(night(c.night)); applies != c.applies {
+			t.Errorf("%s: expected %v, got %v", c.name, c.applies, applies)
+		}
+	}
+}
+
+func TestApplicablePriceRulePriority(t *testing.T) {
+	rules := []PriceRule{
+		{Id: "weekend", Type: WeekendPriceRule, Weekdays: defaultWeekendDays, Price: 120},
+		{Id: "season", Type: SeasonPriceRule, DateFrom: "2027-01-01", DateTo: "2027-01-31", Price: 150},
+		{Id: "seasonWeekend", Type: WeekendPriceRule, DateFrom: "2027-01-01", DateTo: "2027-01-31", Weekdays: []int{int(time.Saturday)}, Price: 200},
+		{Id: "special", Type: SpecialDayPriceRule, DateFrom: "2027-01-09", DateTo: "2027-01-09", Price: 300},
+	}
+
+	cases := []struct {
+		night string
+		rule  string
+	}{
+		{"2027-01-04", "season"},
+		{"2027-01-01", "season"},
+		{"2027-01-02", "seasonWeekend"},
+		{"2027-01-09", "special"},
+		{"2027-03-05", "weekend"},
+		{"2027-03-03", ""},
+	}
+	for _, c := range cases {
+		rule := ApplicablePriceRule(night(c.night), rules)
+		switch {
+		case rule == nil && c.rule != "":
+			t.Errorf("%s: expected rule %s, got none", c.night, c.rule)
+		case rule != nil && rule.Id != c.rule:
+			t.Errorf("%s: expected rule %q, got %s", c.night, c.rule, rule.Id)
+		}
+	}
+}
+
+func TestPriceRuleValidate(t *testing.T) {
+	cases := map[string]PriceRule{
+		"no price":           {Type: SeasonPriceRule, DateFrom: "2027-01-01", DateTo: "2027-01-31"},
+		"negative min":       {Type: SeasonPriceRule, DateFrom: "2027-01-01", DateTo: "2027-01-31", Price: 100, MinNights: -1},
+		"season without end": {Type: SeasonPriceRule, DateFrom: "2027-01-01", Price: 100},
+		"inverted season":    {Type: SeasonPriceRule, DateFrom: "2027-02-01", DateTo: "2027-01-01", Price: 100},
+		"bad weekday":        {Type: WeekendPriceRule, Weekdays: []int{7}, Price: 100},
+		"bad date":           {Type: SpecialDayPriceRule, DateFrom: "01/01/2027", Price: 100},
+		"unknown type":       {Type: "Holiday", Price: 100},
+	}
+	for name, rule := range cases {
+		if err := rule.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	weekend := PriceRule{Type: WeekendPriceRule, Price: 100}
+	if err := weekend.Validate(); err != nil || len(weekend.Weekdays) != 2 {
+		t.Errorf("expected weekend rule to default to friday and saturday, got %v %v", weekend.Weekdays, err)
+	}
+}
@@ -1,52 +1,79 @@
 package my_models
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
+
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
 type Property struct {
-	Id               string      `json:"id" db:"id"`
-	Name             string      `json:"name" db:"name"`
-	AdultQuantity    int         `json:"adultQuantity" db:"adultQuantity"`
-	KidQuantity      int         `json:"kidQuantity" db:"kidQuantity"`
-	KingSizedBeds    int         `json:"kingSizedBeds" db:"kingSizedBeds"`
-	SingleBeds       int         `json:"singleBeds" db:"singleBeds"`
-	HasAC            string      `json:"hasAC" db:"hasAC"`
-	HasWIFI          string      `json:"hasWIFI" db:"hasWIFI"`
-	HasGarage        string      `json:"hasGarage" db:"hasGarage"`
-	Type             int         `json:"type" db:"type"`
-	BeachDistance    int         `json:"beachDistance" db:"beachDistance"`
-	State            string      `json:"state" db:"state"`
-	Resort           string      `json:"resort" db:"resort"`
-	Neighborhood     string      `json:"neighborhood" db:"neighborhood"`
-	UnavailableDates []DateRange `json:"unavailableDates" db:"unavailableDates"`
-	IsPendingPayment bool        `json:"isPendingPayment" db:"isPendingPayment"`
-	Paid             bool        `json:"paid" db:"paid"`
-	Owner            string      `json:"owner" db:"owner"`
-	BookingPrice     int         `json:"bookingPrice" db:"bookingPrice"`
-	Images           []string    `json:"images" db:"images"`
+	Id               string           `json:"id" db:"id"`
+	Name             string           `json:"name" db:"name"`
+	AdultQuantity    int              `json:"adultQuantity" db:"adultQuantity"`
+	KidQuantity      int              `json:"kidQuantity" db:"kidQuantity"`
+	KingSizedBeds    int              `json:"kingSizedBeds" db:"kingSizedBeds"`
+	SingleBeds       int              `json:"singleBeds" db:"singleBeds"`
+	HasAC            string           `json:"hasAC" db:"hasAC"`
+	HasWIFI          string           `json:"hasWIFI" db:"hasWIFI"`
+	HasGarage        string           `json:"hasGarage" db:"hasGarage"`
+	Type             int              `json:"type" db:"type"`
+	BeachDistance    int              `json:"beachDistance" db:"beachDistance"`
+	State            string           `json:"state" db:"state"`
+	Resort           string           `json:"resort" db:"resort"`
+	Neighborhood     string           `json:"neighborhood" db:"neighborhood"`
+	UnavailableDates []DateRange      `json:"unavailableDates" db:"unavailableDates"`
+	IsPendingPayment bool             `json:"isPendingPayment" db:"isPendingPayment"`
+	Paid             bool             `json:"paid" db:"paid"`
+	Owner            string           `json:"owner" db:"owner"`
+	BookingPrice     int              `json:"bookingPrice" db:"bookingPrice"`
+	Images           []string         `json:"images" db:"images"`
+	InstantBook      bool             `json:"instantBook" db:"instantBook"`
+	InstantBookRules InstantBookRules `json:"instantBookRules" db:"instantBookRules"`
+	CleaningFee      float64          `json:"cleaningFee" db:"cleaningFee"`
+	BookingRules     BookingRules     `json:"bookingRules" db:"bookingRules"`
+	// CancellationPolicy is nil when the property follows the country cancellation settings
+	CancellationPolicy *CancellationPolicy `json:"cancellationPolicy" db:"cancellationPolicy"`
+	// CheckInInstructions are mailed to the tenant before arrival
+	CheckInInstructions string       `json:"checkInInstructions" db:"checkInInstructions"`
+	PaymentTerms        PaymentTerms `json:"paymentTerms" db:"paymentTerms"`
+}
+
+// InstantBookRules are the optional conditions a reservation must meet to be
+// approved without waiting for an Admin. Zero values disable a rule.
+type InstantBookRules struct {
+	MinPreviousStays int `json:"minPreviousStays"`
+	MaxNights        int `json:"maxNights"`
 }
 
 type PropertyDBO struct {
-	Id               string `json:"id" db:"id"`
-	Name             string `json:"name" db:"name"`
-	AdultQuantity    int    `json:"adultQuantity" db:"adultQuantity"`
-	KidQuantity      int    `json:"kidQuantity" db:"kidQuantity"`
-	KingSizedBeds    int    `json:"kingSizedBeds" db:"kingSizedBeds"`
-	SingleBeds       int    `json:"singleBeds" db:"singleBeds"`
-	HasAC            bool   `json:"hasAC" db:"hasAC"`
-	HasWIFI          bool   `json:"hasWIFI" db:"hasWIFI"`
-	HasGarage        bool   `json:"hasGarage" db:"hasGarage"`
-	Type             int    `json:"type" db:"type"`
-	BeachDistance    int    `json:"beachDistance" db:"beachDistance"`
-	State            string `json:"state" db:"state"`
-	Resort           string `json:"resort" db:"resort"`
-	Neighborhood     string `json:"neighborhood" db:"neighborhood"`
-	UnavailableDates string `json:"unavailableDates" db:"unavailableDates"`
-	IsPendingPayment bool   `json:"isPendingPayment" db:"isPendingPayment"`
-	Paid             bool   `json:"paid" db:"paid"`
-	Owner            string `json:"owner" db:"owner"`
-	BookingPrice     int    `json:"bookingPrice" db:"bookingPrice"`
+	Id                  string        `json:"id" db:"id"`
+	Name                string        `json:"name" db:"name"`
+	AdultQuantity       int           `json:"adultQuantity" db:"adultQuantity"`
+	KidQuantity         int           `json:"kidQuantity" db:"kidQuantity"`
+	KingSizedBeds       int           `json:"kingSizedBeds" db:"kingSizedBeds"`
+	SingleBeds          int           `json:"singleBeds" db:"singleBeds"`
+	HasAC               bool          `json:"hasAC" db:"hasAC"`
+	HasWIFI             bool          `json:"hasWIFI" db:"hasWIFI"`
+	HasGarage           bool          `json:"hasGarage" db:"hasGarage"`
+	Type                int           `json:"type" db:"type"`
+	BeachDistance       int           `json:"beachDistance" db:"beachDistance"`
+	State               string        `json:"state" db:"state"`
+	Resort              string        `json:"resort" db:"resort"`
+	Neighborhood        string        `json:"neighborhood" db:"neighborhood"`
+	UnavailableDates    string        `json:"unavailableDates" db:"unavailableDates"`
+	IsPendingPayment    bool          `json:"isPendingPayment" db:"isPendingPayment"`
+	Paid                bool          `json:"paid" db:"paid"`
+	Owner               string        `json:"owner" db:"owner"`
+	BookingPrice        int           `json:"bookingPrice" db:"bookingPrice"`
+	InstantBook         bool          `json:"instantBook" db:"instantBook"`
+	InstantBookRules    types.JsonRaw `json:"instantBookRules" db:"instantBookRules"`
+	CleaningFee         float64       `json:"cleaningFee" db:"cleaningFee"`
+	BookingRules        types.JsonRaw `json:"bookingRules" db:"bookingRules"`
+	CancellationPolicy  types.JsonRaw `json:"cancellationPolicy" db:"cancellationPolicy"`
+	CheckInInstructions string        `json:"checkInInstructions" db:"checkInInstructions"`
+	PaymentTerms        types.JsonRaw `json:"paymentTerms" db:"paymentTerms"`
 }
 
 type PropertyFilter struct {
@@ -73,29 +100,71 @@ type PropertyFilter struct {
 	Neighborhood     *string `json:"neighborhood"`
 }
 
+// ToObject decodes the json columns, which are NULL for properties created
+// before those columns were added.
 func (p *PropertyDBO) ToObject(unavailableDates []DateRange, images []string) Property {
+	var instantBookRules InstantBookRules
+	if len(p.InstantBookRules) > 0 {
+		json.Unmarshal(p.InstantBookRules, &instantBookRules)
+	}
+
+	var bookingRules BookingRules
+	if len(p.BookingRules) > 0 {
+		json.Unmarshal(p.BookingRules, &bookingRules)
+	}
+
+	var cancellationPolicy *CancellationPolicy
+	if len(p.CancellationPolicy) > 0 {
+		json.Unmarshal(p.CancellationPolicy, &cancellationPolicy)
+	}
+
+	var paymentTerms PaymentTerms
+	if len(p.PaymentTerms) > 0 {
+		json.Unmarshal(p.PaymentTerms, &paymentTerms)
+	}
+
 	return Property{
-		Id:               p.Id,
-		Name:             p.Name,
-		AdultQuantity:    p.AdultQuantity,
-		KidQuantity:      p.KidQuantity,
-		KingSizedBeds:    p.KingSizedBeds,
-		SingleBeds:       p.SingleBeds,
-		HasAC:            toString(p.HasAC),
-		HasWIFI:          toString(p.HasWIFI),
-		HasGarage:        toString(p.HasGarage),
-		Type:             p.Type,
-		BeachDistance:    p.BeachDistance,
-		State:            p.State,
-		Resort:           p.Resort,
-		Neighborhood:     p.Neighborhood,
-		UnavailableDates: unavailableDates,
-		IsPendingPayment: p.IsPendingPayment,
-		Paid:             p.Paid,
-		Owner:            p.Owner,
-		BookingPrice:     p.BookingPrice,
-		Images:           images,
+		Id:                  p.Id,
+		Name:                p.Name,
+		AdultQuantity:       p.AdultQuantity,
+		KidQuantity:         p.KidQuantity,
+		KingSizedBeds:       p.KingSizedBeds,
+		SingleBeds:          p.SingleBeds,
+		HasAC:               toString(p.HasAC),
+		HasWIFI:             toString(p.HasWIFI),
+		HasGarage:           toString(p.HasGarage),
+		Type:                p.Type,
+		BeachDistance:       p.BeachDistance,
+		State:               p.State,
+		Resort:              p.Resort,
+		Neighborhood:        p.Neighborhood,
+		UnavailableDates:    unavailableDates,
+		IsPendingPayment:    p.IsPendingPayment,
+		Paid:                p.Paid,
+		Owner:               p.Owner,
+		BookingPrice:        p.BookingPrice,
+		Images:              images,
+		InstantBook:         p.InstantBook,
+		InstantBookRules:    instantBookRules,
+		CleaningFee:         p.CleaningFee,
+		BookingRules:        bookingRules,
+		CancellationPolicy:  cancellationPolicy,
+		CheckInInstructions: p.CheckInInstructions,
+		PaymentTerms:        paymentTerms,
+	}
+}
+
+// Check returns a descriptive error for the first rule the reservation does not meet.
+func (r InstantBookRules) Check(nights int, previousStays int) error {
+	if r.MaxNights > 0 && nights > r.MaxNights {
+		return fmt.Errorf("stays longer than %d nights require manual approval", r.MaxNights)
+	}
+
+	if previousStays < r.MinPreviousStays {
+		return fmt.Errorf("tenant needs at least %d completed stays to book instantly", r.MinPreviousStays)
 	}
+
+	return nil
 }
 
 func toString(b bool) string {
@@ -137,21 +206,28 @@ func (r *DateRange) ToMap(propertyId string) map[string]interface{} {
 
 func (r *Property) ToMap() map[string]interface{} {
 	return map[string]interface{}{
-		"name":             r.Name,
-		"adultQuantity":    r.AdultQuantity,
-		"kidQuantity":      r.KidQuantity,
-		"kingSizedBeds":    r.KingSizedBeds,
-		"singleBeds":       r.SingleBeds,
-		"hasAC":            r.HasAC,
-		"hasWIFI":          r.HasWIFI,
-		"hasGarage":        r.HasGarage,
-		"type":             r.Type,
-		"beachDistance":    r.BeachDistance,
-		"state":            r.State,
-		"resort":           r.Resort,
-		"neighborhood":     r.Neighborhood,
-		"isPendingPayment": r.IsPendingPayment,
-		"owner":            r.Owner,
-		"bookingPrice":     r.BookingPrice,
+		"name":                r.Name,
+		"adultQuantity":       r.AdultQuantity,
+		"kidQuantity":         r.KidQuantity,
+		"kingSizedBeds":       r.KingSizedBeds,
+		"singleBeds":          r.SingleBeds,
+		"hasAC":               r.HasAC,
+		"hasWIFI":             r.HasWIFI,
+		"hasGarage":           r.HasGarage,
+		"type":                r.Type,
+		"beachDistance":       r.BeachDistance,
+		"state":               r.State,
+		"resort":              r.Resort,
+		"neighborhood":        r.Neighborhood,
+		"isPendingPayment":    r.IsPendingPayment,
+		"owner":               r.Owner,
+		"bookingPrice":        r.BookingPrice,
+		"instantBook":         r.InstantBook,
+		"instantBookRules":    r.InstantBookRules,
+		"cleaningFee":         r.CleaningFee,
+		"bookingRules":        r.BookingRules,
+		"cancellationPolicy":  r.CancellationPolicy,
+		"checkInInstructions": r.CheckInInstructions,
+		"paymentTerms":        r.PaymentTerms,
 	}
 }
@@ -2,30 +2,35 @@ package my_models
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
 type ReservationModel struct {
-	ID            string `json:"id" db:"id"`
-	Document      string `json:"document" db:"document"`
-	Name          string `json:"name" db:"name"`
-	LastName      string `json:"last_name" db:"last_name"`
-	Email         string `json:"email" db:"email"`
-	Phone         string `json:"phone" db:"phone"`
-	Address       string `json:"address" db:"address"`
-	Nationality   string `json:"nationality" db:"nationality"`
-	Country       string `json:"country" db:"country"`
-	Adults        int    `json:"adults" db:"adults"`
-	Minors        int    `json:"minors" db:"minors"`
-	PropertyId    string `json:"property" db:"property"`
-	ReservedFrom  string `json:"reserved_from" db:"reserved_from"`
-	ReservedUntil string `json:"reserved_until" db:"reserved_until"`
-	Status        string `json:"status" db:"status"`
-	CheckIn       string `json:"check_in" db:"check_in"`
-	CheckOut      string `json:"check_out" db:"check_out"`
+	ID                 string `json:"id" db:"id"`
+	Document           string `json:"document" db:"document"`
+	Name               string `json:"name" db:"name"`
+	LastName           string `json:"last_name" db:"last_name"`
+	Email              string `json:"email" db:"email"`
+	Phone              string `json:"phone" db:"phone"`
+	Address            string `json:"address" db:"address"`
+	Nationality        string `json:"nationality" db:"nationality"`
+	Country            string `json:"country" db:"country"`
+	Adults             int    `json:"adults" db:"adults"`
+	Minors             int    `json:"minors" db:"minors"`
+	PropertyId         string `json:"property" db:"property"`
+	ReservedFrom       string `json:"reserved_from" db:"reserved_from"`
+	ReservedUntil      string `json:"reserved_until" db:"reserved_until"`
+	Status             string `json:"status" db:"status"`
+	CheckIn            string `json:"check_in" db:"check_in"`
+	CheckOut           string `json:"check_out" db:"check_out"`
+	RejectionReason    string `json:"rejection_reason" db:"rejection_reason"`
+	CancellationReason string `json:"cancellation_reason" db:"cancellation_reason"`
+	CancelledBy        string `json:"cancelled_by" db:"cancelled_by"`
+	NeedsReview        bool   `json:"needs_review" db:"needs_review"`
+	ApprovedDate       string `json:"approved_date" db:"approved_date"`
 }
 
-
 type ReservationFilter struct {
 	ReservedFrom   *string `json:"reserved_from"`
 	ReservedUntil  *string `json:"reserved_until"`
@@ -34,6 +39,53 @@ type ReservationFilter struct {
 	TenantEmail    *string `json:"email"`
 	TenantName     *string `json:"name"`
 	TenantLastName *string `json:"lastName"`
+	Page           *int    `json:"page"`
+	Size           *int    `json:"size"`
+	Sort           *string `json:"sort"`
+}
+
+const (
+	CsvExportFormat  = "csv"
+	XlsxExportFormat = "xlsx"
+)
+
+type ReservationsPage struct {
+	Items []ReservationModel `json:"items"`
+	Page  int                `json:"page"`
+	Size  int                `json:"size"`
+	Total int                `json:"total"`
+}
+
+// reservationSortColumns whitelists the columns reservations can be sorted by.
+var reservationSortColumns = map[string]string{
+	"created":        "created",
+	"reserved_from":  "reserved_from",
+	"reserved_until": "reserved_until",
+	"status":         "status",
+	"email":          "email",
+	"name":           "name",
+	"last_name":      "last_name",
+	"country":        "country",
+}
+
+// OrderBy turns the sort parameter into an ORDER BY clause. A leading "-"
+// sorts in descending order, e.g. "-reserved_from".
+func (f *ReservationFilter) OrderBy() (string, error) {
+	if f.Sort == nil || *f.Sort == "" {
+		return "created DESC", nil
+	}
+
+	field, direction := *f.Sort, "ASC"
+	if strings.HasPrefix(field, "-") {
+		field, direction = field[1:], "DESC"
+	}
+
+	column, ok := reservationSortColumns[field]
+	if !ok {
+		return "", fmt.Errorf("reservations cannot be sorted by %s", field)
+	}
+
+	return column + " " + direction, nil
 }
 
 func (r *ReservationModel) ToMap() map[string]interface{} {
@@ -0,0 +1,39 @@
+package my_models
+
+const (
+	PaymentDueReminder    = "payment_due"
+	PreArrivalReminder    = "pre_arrival"
+	CheckOutReminder      = "checkout_morning"
+	ReviewRequestReminder = "review_request"
+)
+
+// ScheduledNotification is a mail that has to be sent at DueAt. DedupeKey
+// identifies the reminder so scheduling it again updates the pending one
+// instead of sending it twice.
+type ScheduledNotification struct {
+	Id            string `json:"id" db:"id"`
+	DedupeKey     string `json:"dedupeKey" db:"dedupeKey"`
+	Kind          string `json:"kind" db:"kind"`
+	ReservationId string `json:"reservationId" db:"reservationId"`
+	Email         string `json:"email" db:"email"`
+	Message       string `json:"message" db:"message"`
+	DueAt         string `json:"dueAt" db:"dueAt"`
+	SentAt        string `json:"sentAt" db:"sentAt"`
+	Attempts      int    `json:"attempts" db:"attempts"`
+	LastError     string `json:"lastError" db:"lastError"`
+}
+
+func ReminderDedupeKey(kind string, reservationId string) string {
+	return kind + ":" + reservationId
+}
+
+func (n *ScheduledNotification) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"dedupeKey":     n.DedupeKey,
+		"kind":          n.Kind,
+		"reservationId": n.ReservationId,
+		"email":         n.Email,
+		"message":       n.Message,
+		"dueAt":         n.DueAt,
+	}
+}
@@ -65,11 +65,11 @@ func (r *ReportStructure) ValidateReport(report mongo_models.SensorReport) (mong
 		if item.Value != "" {
 			regex, err := regexp.Compile(item.Value)
 			if err != nil {
-				logger.Error("invalid regex for type %s", item.Type)
+				logger.Error("invalid regex for type ", item.Type)
 				return mongo_models.SensorReport{}, fmt.Errorf("invalid regex for type %s", item.Type)
 			}
 			if !regex.MatchString(reportMeasure.Value) {
-				logger.Error("invalid value for type %s", item.Type)
+				logger.Error("invalid value for type ", item.Type)
 				return mongo_models.SensorReport{}, fmt.Errorf("invalid value for type %s", item.Type)
 			}
 		} else {
@@ -77,11 +77,11 @@ func (r *ReportStructure) ValidateReport(report mongo_models.SensorReport) (mong
 			maxValue, _ := strconv.ParseFloat(item.Max, 64)
 			reportValue, err := strconv.ParseFloat(reportMeasure.Value, 64)
 			if err != nil {
-				logger.Error("invalid value for type %s", item.Type)
+				logger.Error("invalid value for type ", item.Type)
 				return mongo_models.SensorReport{}, fmt.Errorf("invalid value for type %s", item.Type)
 			}
 			if reportValue < minValue || reportValue > maxValue {
-				logger.Error("value for type %s is out of range", item.Type)
+				logger.Error("value for type ", item.Type, " is out of range")
 				return mongo_models.SensorReport{}, fmt.Errorf("value for type %s is out of range", item.Type)
 			}
 		}
@@ -0,0 +1,60 @@
+package my_models
+
+import "fmt"
+
+const (
+	UpcomingReservations  = "upcoming"
+	PastReservations      = "past"
+	CancelledReservations = "cancelled"
+
+	defaultReservationsPageSize = 10
+	maxReservationsPageSize     = 100
+)
+
+// TenantReservationFilter narrows the reservations of the logged tenant.
+// An empty Scope returns every reservation.
+type TenantReservationFilter struct {
+	Scope string `json:"scope"`
+	Page  int    `json:"page"`
+	Size  int    `json:"size"`
+}
+
+type PropertySummary struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	CoverImage string `json:"cover_image"`
+}
+
+type TenantReservation struct {
+	ReservationModel
+	Property   PropertySummary `json:"property_summary"`
+	AmountPaid float64         `json:"amount_paid"`
+}
+
+type TenantReservationsPage struct {
+	Items []TenantReservation `json:"items"`
+	Page  int                 `json:"page"`
+	Size  int                 `json:"size"`
+	Total int                 `json:"total"`
+}
+
+// Normalize validates the scope and fills in the default page and size.
+func (f *TenantReservationFilter) Normalize() error {
+	switch f.Scope {
+	case "", UpcomingReservations, PastReservations, CancelledReservations:
+	default:
+		return fmt.Errorf("invalid scope %s, valid scopes are %s, %s and %s", f.Scope, UpcomingReservations, PastReservations, CancelledReservations)
+	}
+
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.Size < 1 {
+		f.Size = defaultReservationsPageSize
+	}
+	if f.Size > maxReservationsPageSize {
+		f.Size = maxReservationsPageSize
+	}
+
+	return nil
+}
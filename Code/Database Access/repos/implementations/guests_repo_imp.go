@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+const (
+	guestsCollection = "reservation_guests"
+)
+
+type PocketGuestsRepo struct {
+	Db pocketbase.PocketBase
+}
+
+func (r *PocketGuestsRepo) GetGuests(reservationId string) ([]my_models.Guest, error) {
+	logger.Info("Repo: Getting guests of reservation ", reservationId)
+
+	var guestDBOs []my_models.GuestDBO
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE reservationId = {:reservationId} ORDER BY created", guestsCollection)).
+		Bind(dbx.Params{"reservationId": reservationId}).
+		All(&guestDBOs)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	guests := make([]my_models.Guest, 0, len(guestDBOs))
+	for _, dbo := range guestDBOs {
+		guests = append(guests, dbo.ToObject())
+	}
+
+	logger.Info("Repo: Got guests succesfully")
+	return guests, nil
+}
+
+// ReplaceGuests swaps the whole roster of the reservation in a single transaction.
+func (r *PocketGuestsRepo) ReplaceGuests(reservationId string, guests []my_models.Guest) error {
+	logger.Info("Repo: Replacing guests of reservation ", reservationId)
+
+	collection, err := r.Db.Dao().FindCollectionByNameOrId(guestsCollection)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	err = r.Db.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+		_, err := txDao.DB().
+			NewQuery(fmt.Sprintf("DELETE FROM %s WHERE reservationId = {:reservationId}", guestsCollection)).
+			Bind(dbx.Params{"reservationId": reservationId}).
+			Execute()
+		if err != nil {
+			return err
+		}
+
+		for _, guest := range guests {
+			record := models.NewRecord(collection)
+			form := forms.NewRecordUpsert(r.Db, record)
+			form.SetDao(txDao)
+			form.LoadData(guest.ToMap(reservationId))
+			if err := form.Submit(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	logger.Info("Repo: Guests replaced succesfully")
+	return nil
+}
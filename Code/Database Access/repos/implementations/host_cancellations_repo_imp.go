@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+const (
+	hostCancellationsCollection = "host_cancellations"
+)
+
+type PocketHostCancellationsRepo struct {
+	Db pocketbase.PocketBase
+}
+
+func (r *PocketHostCancellationsRepo) AddHostCancellation(cancellation my_models.HostCancellation) error {
+	logger.Info("Repo: Recording host cancellation of reservation ", cancellation.ReservationId)
+	collection, err := r.Db.Dao().FindCollectionByNameOrId(hostCancellationsCollection)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	record := models.NewRecord(collection)
+	form := forms.NewRecordUpsert(r.Db, record)
+	form.LoadData(cancellation.ToMap())
+
+	if err := form.Submit(); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	logger.Info("Repo: Host cancellation recorded with id ", record.Id)
+	return nil
+}
+
+// GetFrequentCancellers groups the host cancellations of the period by owner and
+// keeps the owners with at least minCancellations of them.
+func (r *PocketHostCancellationsRepo) GetFrequentCancellers(fromDate time.Time, untilDate time.Time, minCancellations int) ([]my_models.HostCancellationsReportItem, error) {
+	logger.Info("Repo: Getting frequent host cancellers")
+
+	var items []my_models.HostCancellationsReportItem
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			SELECT hc.owner AS owner, u.email AS owner_email, COUNT(*) AS cancellations, SUM(hc.penalty) AS total_penalty
+			FROM %s hc
+			LEFT JOIN %s u ON u.id = hc.owner
+			WHERE hc.created >= {:fromDate} AND hc.created < {:untilDate}
+			GROUP BY hc.owner, u.email
+			HAVING COUNT(*) >= {:minCancellations}
+			ORDER BY cancellations DESC
+			`, hostCancellationsCollection, usersCollectionName)).
+		Bind(dbx.Params{
+			"fromDate":         fromDate.Format(time.DateOnly),
+			"untilDate":        untilDate.AddDate(0, 0, 1).Format(time.DateOnly),
+			"minCancellations": minCancellations,
+		}).
+		All(&items)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	logger.Info("Repo: Got frequent host cancellers succesfully")
+	return items, nil
+}
@@ -0,0 +1,194 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+const (
+	idempotencyKeysCollection = "idempotency_keys"
+	idempotencyCachePrefix    = "idempotency:"
+)
+
+// PocketIdempotencyRepo keeps idempotency records in Redis and falls back to
+// PocketBase when Redis is not available.
+type PocketIdempotencyRepo struct {
+	Db    pocketbase.PocketBase
+	Cache *redis.Client
+}
+
+// Reserve stores the record as in progress unless its key is already taken, in
+// which case the stored record is returned.
+func (r *PocketIdempotencyRepo) Reserve(record my_models.IdempotencyRecord) (*my_models.IdempotencyRecord, error) {
+	ttl, err := idempotencyTTL(record)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Cache != nil {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+
+		reserved, err := r.Cache.SetNX(ctx, idempotencyCachePrefix+record.Key, value, ttl).Result()
+		if err == nil {
+			if reserved {
+				return nil, nil
+			}
+			return r.getFromCache(record.Key)
+		}
+		logger.Warn("Repo: Redis unavailable for idempotency keys, using pocketbase: ", err)
+	}
+
+	return r.reserveInDB(record)
+}
+
+func (r *PocketIdempotencyRepo) Complete(record my_models.IdempotencyRecord) error {
+	ttl, err := idempotencyTTL(record)
+	if err != nil {
+		return err
+	}
+
+	if r.Cache != nil {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		err = r.Cache.Set(ctx, idempotencyCachePrefix+record.Key, value, ttl).Err()
+		if err == nil {
+			return nil
+		}
+		logger.Warn("Repo: Redis unavailable for idempotency keys, using pocketbase: ", err)
+	}
+
+	dbRecord, err := r.Db.Dao().FindFirstRecordByData(idempotencyKeysCollection, "key", record.Key)
+	if err != nil {
+		collection, err := r.Db.Dao().FindCollectionByNameOrId(idempotencyKeysCollection)
+		if err != nil {
+			logger.Error("Repo: ", err)
+			return err
+		}
+		dbRecord = models.NewRecord(collection)
+	}
+
+	form := forms.NewRecordUpsert(r.Db, dbRecord)
+	form.LoadData(record.ToMap())
+	if err := form.Submit(); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// Release frees the key so the request can be retried with it.
+func (r *PocketIdempotencyRepo) Release(key string) error {
+	if r.Cache != nil {
+		if err := r.Cache.Del(ctx, idempotencyCachePrefix+key).Err(); err != nil {
+			logger.Warn("Repo: Could not release idempotency key in redis: ", err)
+		}
+	}
+
+	_, err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("DELETE FROM %s WHERE key = {:key}", idempotencyKeysCollection)).
+		Bind(dbx.Params{"key": key}).
+		Execute()
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PocketIdempotencyRepo) getFromCache(key string) (*my_models.IdempotencyRecord, error) {
+	value, err := r.Cache.Get(ctx, idempotencyCachePrefix+key).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("idempotency key was just released, retry the request")
+	}
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	var stored my_models.IdempotencyRecord
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+func (r *PocketIdempotencyRepo) reserveInDB(record my_models.IdempotencyRecord) (*my_models.IdempotencyRecord, error) {
+	// Expired keys are purged here since there is no TTL outside of Redis
+	_, err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("DELETE FROM %s WHERE expiresAt < {:now}", idempotencyKeysCollection)).
+		Bind(dbx.Params{"now": time.Now().UTC().Format(my_models.PocketTimeLayout)}).
+		Execute()
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	if stored, err := r.getFromDB(record.Key); stored != nil || err != nil {
+		return stored, err
+	}
+
+	collection, err := r.Db.Dao().FindCollectionByNameOrId(idempotencyKeysCollection)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	form := forms.NewRecordUpsert(r.Db, models.NewRecord(collection))
+	form.LoadData(record.ToMap())
+	if err := form.Submit(); err != nil {
+		// The unique index rejects a concurrent request that reserved the key first
+		if stored, _ := r.getFromDB(record.Key); stored != nil {
+			return stored, nil
+		}
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (r *PocketIdempotencyRepo) getFromDB(key string) (*my_models.IdempotencyRecord, error) {
+	var stored my_models.IdempotencyRecord
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE key = {:key}", idempotencyKeysCollection)).
+		Bind(dbx.Params{"key": key}).
+		One(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+func idempotencyTTL(record my_models.IdempotencyRecord) (time.Duration, error) {
+	expiresAt, err := time.Parse(my_models.PocketTimeLayout, record.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return time.Until(expiresAt), nil
+}
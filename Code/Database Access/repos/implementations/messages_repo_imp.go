@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	messagesCollection = "reservation_messages"
+)
+
+type PocketMessagesRepo struct {
+	Db pocketbase.PocketBase
+}
+
+func (r *PocketMessagesRepo) GetMessages(reservationId string) ([]my_models.Message, error) {
+	logger.Info("Repo: Getting messages of reservation ", reservationId)
+
+	var messageDBOs []my_models.MessageDBO
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE reservationId = {:reservationId} ORDER BY created", messagesCollection)).
+		Bind(dbx.Params{"reservationId": reservationId}).
+		All(&messageDBOs)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	messages := make([]my_models.Message, 0, len(messageDBOs))
+	for _, dbo := range messageDBOs {
+		messages = append(messages, dbo.ToObject())
+	}
+
+	logger.Info("Repo: Got messages succesfully")
+	return messages, nil
+}
+
+func (r *PocketMessagesRepo) AddMessage(message my_models.Message) (my_models.Message, error) {
+	logger.Info("Repo: Adding message to reservation ", message.ReservationId)
+
+	collection, err := r.Db.Dao().FindCollectionByNameOrId(messagesCollection)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return my_models.Message{}, err
+	}
+
+	record := models.NewRecord(collection)
+	form := forms.NewRecordUpsert(r.Db, record)
+	form.LoadData(message.ToMap())
+	if err := form.Submit(); err != nil {
+		logger.Error("Repo: ", err)
+		return my_models.Message{}, err
+	}
+
+	message.Id = record.Id
+	message.Created = record.GetCreated().String()
+
+	logger.Info("Repo: Message added succesfully")
+	return message, nil
+}
+
+// MarkMessagesRead sets the read receipt of every unread message the other
+// party sent in the conversation and returns how many were marked.
+func (r *PocketMessagesRepo) MarkMessagesRead(reservationId string, readerRole string) (int64, error) {
+	logger.Info("Repo: Marking messages of reservation ", reservationId, " as read by ", readerRole)
+
+	result, err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("UPDATE %s SET readAt = {:readAt}, updated = {:readAt} WHERE reservationId = {:reservationId} AND senderRole != {:readerRole} AND (readAt IS NULL OR readAt = '')", messagesCollection)).
+		Bind(dbx.Params{"readAt": types.NowDateTime().String(), "reservationId": reservationId, "readerRole": readerRole}).
+		Execute()
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return 0, err
+	}
+
+	marked, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return 0, err
+	}
+
+	logger.Info("Repo: Marked ", marked, " messages as read")
+	return marked, nil
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+const (
+	paymentEventsCollection = "payment_events"
+)
+
+// PocketPaymentEventsRepo remembers the webhook events already handled so a
+// redelivered one is not applied twice.
+type PocketPaymentEventsRepo struct {
+	Db pocketbase.PocketBase
+}
+
+// AddEvent stores the event and tells whether it is the first time it is seen.
+func (r *PocketPaymentEventsRepo) AddEvent(event my_models.PaymentEvent) (bool, error) {
+	logger.Info("Repo: Adding payment event ", event.Id)
+
+	if seen, err := r.eventExists(event.Id); seen || err != nil {
+		return false, err
+	}
+
+	collection, err := r.Db.Dao().FindCollectionByNameOrId(paymentEventsCollection)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return false, err
+	}
+
+	form := forms.NewRecordUpsert(r.Db, models.NewRecord(collection))
+	form.LoadData(event.ToMap())
+	if err := form.Submit(); err != nil {
+		// The unique index rejects a concurrent delivery of the same event
+		if seen, _ := r.eventExists(event.Id); seen {
+			return false, nil
+		}
+		logger.Error("Repo: ", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RemoveEvent forgets an event so its next delivery is handled again.
+func (r *PocketPaymentEventsRepo) RemoveEvent(eventId string) error {
+	_, err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("DELETE FROM %s WHERE eventId = {:eventId}", paymentEventsCollection)).
+		Bind(dbx.Params{"eventId": eventId}).
+		Execute()
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PocketPaymentEventsRepo) eventExists(eventId string) (bool, error) {
+	var count int
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE eventId = {:eventId}", paymentEventsCollection)).
+		Bind(dbx.Params{"eventId": eventId}).
+		Row(&count)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
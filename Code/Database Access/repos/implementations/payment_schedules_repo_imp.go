@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+const (
+	paymentSchedulesCollection = "payment_schedules"
+)
+
+type PocketPaymentSchedulesRepo struct {
+	Db pocketbase.PocketBase
+}
+
+func (r *PocketPaymentSchedulesRepo) AddInstallments(reservationId string, installments []my_models.PaymentInstallment) error {
+	logger.Info("Repo: Adding payment schedule for reservation ", reservationId)
+
+	collection, err := r.Db.Dao().FindCollectionByNameOrId(paymentSchedulesCollection)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	err = r.Db.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+		for _, installment := range installments {
+			form := forms.NewRecordUpsert(r.Db, models.NewRecord(collection))
+			form.SetDao(txDao)
+			form.LoadData(installment.ToMap(reservationId))
+			if err := form.Submit(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	logger.Info("Repo: Payment schedule added succesfully")
+	return nil
+}
+
+func (r *PocketPaymentSchedulesRepo) GetInstallments(reservationId string) ([]my_models.PaymentInstallment, error) {
+	logger.Info("Repo: Getting payment schedule of reservation ", reservationId)
+
+	var installmentDBOs []my_models.PaymentInstallmentDBO
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE reservationId = {:reservationId} ORDER BY dueDate", paymentSchedulesCollection)).
+		Bind(dbx.Params{"reservationId": reservationId}).
+		All(&installmentDBOs)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	return toInstallments(installmentDBOs), nil
+}
+
+// GetDueBalances returns the pending balances whose due date (or retry date) passed.
+func (r *PocketPaymentSchedulesRepo) GetDueBalances(now time.Time, maxAttempts int) ([]my_models.PaymentInstallment, error) {
+	logger.Info("Repo: Getting due balances")
+
+	var installmentDBOs []my_models.PaymentInstallmentDBO
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			SELECT *
+			FROM %s
+			WHERE kind = {:kind}
+			AND status = {:status}
+			AND dueDate <= {:now}
+			AND attempts < {:maxAttempts}
+			`, paymentSchedulesCollection)).
+		Bind(dbx.Params{
+			"kind":        my_models.BalanceInstallment,
+			"status":      my_models.InstallmentPending,
+			"now":         now.UTC().Format(my_models.PocketTimeLayout),
+			"maxAttempts": maxAttempts,
+		}).
+		All(&installmentDBOs)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	logger.Info("Repo: Got due balances succesfully")
+	return toInstallments(installmentDBOs), nil
+}
+
+// MarkInstallmentPaid also forgets the stored card token, it is not needed anymore.
+func (r *PocketPaymentSchedulesRepo) MarkInstallmentPaid(id string) error {
+	record, err := r.Db.Dao().FindRecordById(paymentSchedulesCollection, id)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	record.Set("status", my_models.InstallmentPaid)
+	record.Set("paidAt", time.Now())
+	record.Set("attempts", record.GetInt("attempts")+1)
+	record.Set("lastError", "")
+	record.Set("cardToken", "")
+	if err := r.Db.Dao().SaveRecord(record); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// MarkInstallmentFailed records a failed charge. The installment is retried at
+// retryAt unless it is the final attempt, which marks it as Failed.
+func (r *PocketPaymentSchedulesRepo) MarkInstallmentFailed(id string, reason string, retryAt time.Time, final bool) error {
+	record, err := r.Db.Dao().FindRecordById(paymentSchedulesCollection, id)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	record.Set("attempts", record.GetInt("attempts")+1)
+	record.Set("lastError", reason)
+	if final {
+		record.Set("status", my_models.InstallmentFailed)
+		record.Set("cardToken", "")
+	} else {
+		record.Set("dueDate", retryAt)
+	}
+	if err := r.Db.Dao().SaveRecord(record); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PocketPaymentSchedulesRepo) CancelPendingInstallments(reservationId string) error {
+	logger.Info("Repo: Cancelling pending installments of reservation ", reservationId)
+
+	_, err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			UPDATE %s
+			SET status = {:cancelled}, cardToken = ''
+			WHERE reservationId = {:reservationId}
+			AND status = {:pending}
+			`, paymentSchedulesCollection)).
+		Bind(dbx.Params{
+			"cancelled":     my_models.InstallmentCancelled,
+			"pending":       my_models.InstallmentPending,
+			"reservationId": reservationId,
+		}).
+		Execute()
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func toInstallments(installmentDBOs []my_models.PaymentInstallmentDBO) []my_models.PaymentInstallment {
+	installments := make([]my_models.PaymentInstallment, 0, len(installmentDBOs))
+	for _, dbo := range installmentDBOs {
+		installments = append(installments, dbo.ToObject())
+	}
+	return installments
+}
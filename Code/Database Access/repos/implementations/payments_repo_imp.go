@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+const (
+	paymentsCollection = "payments"
+)
+
+type PocketPaymentsRepo struct {
+	Db       pocketbase.PocketBase
+	currency string
+}
+
+func (r *PocketPaymentsRepo) SetConfigValues(currency string) {
+	r.currency = currency
+}
+
+// AddPayment writes a ledger row. Payments without a currency are recorded in
+// the configured one.
+func (r *PocketPaymentsRepo) AddPayment(payment my_models.Payment) (my_models.Payment, error) {
+	logger.Info("Repo: Adding ", payment.Kind, " payment of ", payment.Amount)
+
+	collection, err := r.Db.Dao().FindCollectionByNameOrId(paymentsCollection)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return my_models.Payment{}, err
+	}
+
+	if payment.Currency == "" {
+		payment.Currency = r.currency
+	}
+
+	record := models.NewRecord(collection)
+	form := forms.NewRecordUpsert(r.Db, record)
+	form.LoadData(payment.ToMap())
+	if err := form.Submit(); err != nil {
+		logger.Error("Repo: ", err)
+		return my_models.Payment{}, err
+	}
+
+	payment.Id = record.Id
+	payment.Created = record.GetCreated().String()
+	payment.Updated = record.GetUpdated().String()
+
+	logger.Info("Repo: Payment ", payment.Id, " added succesfully")
+	return payment, nil
+}
+
+func (r *PocketPaymentsRepo) GetPaymentById(id string) (my_models.Payment, error) {
+	logger.Info("Repo: Getting payment ", id)
+
+	var payment my_models.Payment
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE id = {:id}", paymentsCollection)).
+		Bind(dbx.Params{"id": id}).
+		One(&payment)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return my_models.Payment{}, fmt.Errorf("payment %s not found", id)
+	}
+
+	return payment, nil
+}
+
+func (r *PocketPaymentsRepo) GetPaymentByReference(gatewayReference string) (my_models.Payment, error) {
+	logger.Info("Repo: Getting payment with reference ", gatewayReference)
+
+	var payment my_models.Payment
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE gatewayReference = {:reference}", paymentsCollection)).
+		Bind(dbx.Params{"reference": gatewayReference}).
+		One(&payment)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return my_models.Payment{}, fmt.Errorf("no payment with reference %s", gatewayReference)
+	}
+
+	return payment, nil
+}
+
+func (r *PocketPaymentsRepo) UpdatePaymentResult(id string, status string, gatewayReference string, failureReason string) error {
+	logger.Info("Repo: Updating payment ", id, " to ", status)
+
+	record, err := r.Db.Dao().FindRecordById(paymentsCollection, id)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	record.Set("status", status)
+	record.Set("gatewayReference", gatewayReference)
+	record.Set("failureReason", failureReason)
+	if err := r.Db.Dao().SaveRecord(record); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PocketPaymentsRepo) GetReservationPayments(reservationId string) ([]my_models.Payment, error) {
+	logger.Info("Repo: Getting payments of reservation ", reservationId)
+
+	var payments []my_models.Payment
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE reservationId = {:reservationId} ORDER BY created", paymentsCollection)).
+		Bind(dbx.Params{"reservationId": reservationId}).
+		All(&payments)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	if payments == nil {
+		payments = []my_models.Payment{}
+	}
+
+	logger.Info("Repo: Got payments succesfully")
+	return payments, nil
+}
+
+func (r *PocketPaymentsRepo) GetPropertyPayments(propertyId string) ([]my_models.Payment, error) {
+	logger.Info("Repo: Getting payments of property ", propertyId)
+
+	var payments []my_models.Payment
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE propertyId = {:propertyId} ORDER BY created", paymentsCollection)).
+		Bind(dbx.Params{"propertyId": propertyId}).
+		All(&payments)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	if payments == nil {
+		payments = []my_models.Payment{}
+	}
+
+	logger.Info("Repo: Got payments succesfully")
+	return payments, nil
+}
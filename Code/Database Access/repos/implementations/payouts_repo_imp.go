@@ -0,0 +1,259 @@
+package repositories
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+const (
+	payoutsCollection       = "payouts"
+	payoutBatchesCollection = "payout_batches"
+)
+
+type PocketPayoutsRepo struct {
+	Db pocketbase.PocketBase
+}
+
+// GetStaysAwaitingPayout returns the paid reservations that were checked in and
+// have no payout yet.
+func (r *PocketPayoutsRepo) GetStaysAwaitingPayout() ([]my_models.ReservationModel, error) {
+	logger.Info("Repo: Getting stays awaiting a payout")
+
+	var reservations []my_models.ReservationModel
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			SELECT r.*
+			FROM %s r
+			LEFT JOIN %s p ON p.reservationId = r.id
+			WHERE r.status = 'Paid'
+			AND r.check_in != ''
+			AND p.id IS NULL
+			`, reservationsCollectionName, payoutsCollection)).
+		All(&reservations)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	logger.Info("Repo: Got stays awaiting a payout succesfully")
+	return reservations, nil
+}
+
+func (r *PocketPayoutsRepo) AddPayout(payout my_models.Payout) (my_models.Payout, error) {
+	logger.Info("Repo: Adding payout for reservation ", payout.ReservationId)
+
+	collection, err := r.Db.Dao().FindCollectionByNameOrId(payoutsCollection)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return my_models.Payout{}, err
+	}
+
+	record := models.NewRecord(collection)
+	form := forms.NewRecordUpsert(r.Db, record)
+	form.LoadData(payout.ToMap())
+	if err := form.Submit(); err != nil {
+		logger.Error("Repo: ", err)
+		return my_models.Payout{}, err
+	}
+
+	payout.Id = record.Id
+	payout.Created = record.GetCreated().String()
+	payout.Updated = record.GetUpdated().String()
+
+	logger.Info("Repo: Payout ", payout.Id, " added succesfully")
+	return payout, nil
+}
+
+// GetDuePayouts returns the scheduled payouts whose due date passed.
+func (r *PocketPayoutsRepo) GetDuePayouts(now time.Time) ([]my_models.Payout, error) {
+	logger.Info("Repo: Getting due payouts")
+
+	var payouts []my_models.Payout
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			SELECT *
+			FROM %s
+			WHERE status = {:status}
+			AND dueAt <= {:now}
+			ORDER BY dueAt
+			`, payoutsCollection)).
+		Bind(dbx.Params{
+			"status": my_models.PayoutScheduled,
+			"now":    now.UTC().Format(my_models.PocketTimeLayout),
+		}).
+		All(&payouts)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	logger.Info("Repo: Got due payouts succesfully")
+	return payouts, nil
+}
+
+func (r *PocketPayoutsRepo) CancelPayout(id string) error {
+	logger.Info("Repo: Cancelling payout ", id)
+
+	record, err := r.Db.Dao().FindRecordById(payoutsCollection, id)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	record.Set("status", my_models.PayoutCancelled)
+	if err := r.Db.Dao().SaveRecord(record); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// AddBatch groups the payouts of an owner in a new batch that is being processed.
+func (r *PocketPayoutsRepo) AddBatch(ownerId string, payouts []my_models.Payout) (my_models.PayoutBatch, error) {
+	logger.Info("Repo: Adding payout batch for owner ", ownerId)
+
+	collection, err := r.Db.Dao().FindCollectionByNameOrId(payoutBatchesCollection)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return my_models.PayoutBatch{}, err
+	}
+
+	total := 0.0
+	for _, payout := range payouts {
+		total += payout.Net
+	}
+	batch := my_models.PayoutBatch{
+		OwnerId: ownerId,
+		Status:  my_models.BatchProcessing,
+		Total:   my_models.RoundPrice(total),
+		Count:   len(payouts),
+	}
+
+	err = r.Db.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+		record := models.NewRecord(collection)
+		record.Set("ownerId", batch.OwnerId)
+		record.Set("status", batch.Status)
+		record.Set("total", batch.Total)
+		record.Set("count", batch.Count)
+		if err := txDao.SaveRecord(record); err != nil {
+			return err
+		}
+		batch.Id = record.Id
+		batch.Created = record.GetCreated().String()
+
+		for _, payout := range payouts {
+			payoutRecord, err := txDao.FindRecordById(payoutsCollection, payout.Id)
+			if err != nil {
+				return err
+			}
+			payoutRecord.Set("batchId", batch.Id)
+			payoutRecord.Set("status", my_models.PayoutBatched)
+			if err := txDao.SaveRecord(payoutRecord); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return my_models.PayoutBatch{}, err
+	}
+
+	logger.Info("Repo: Payout batch ", batch.Id, " added succesfully")
+	return batch, nil
+}
+
+// SettleBatch closes a batch. The payouts of a failed batch are scheduled
+// again so the next run retries them.
+func (r *PocketPayoutsRepo) SettleBatch(batchId string, status string) error {
+	logger.Info("Repo: Settling payout batch ", batchId, " as ", status)
+
+	payoutStatus := my_models.PayoutPaid
+	if status == my_models.BatchFailed {
+		payoutStatus = my_models.PayoutScheduled
+	}
+
+	err := r.Db.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+		record, err := txDao.FindRecordById(payoutBatchesCollection, batchId)
+		if err != nil {
+			return err
+		}
+		record.Set("status", status)
+		record.Set("processedAt", time.Now())
+		if err := txDao.SaveRecord(record); err != nil {
+			return err
+		}
+
+		payouts, err := txDao.FindRecordsByExpr(payoutsCollection, dbx.HashExp{"batchId": batchId})
+		if err != nil {
+			return err
+		}
+		for _, payout := range payouts {
+			payout.Set("status", payoutStatus)
+			if status == my_models.BatchFailed {
+				payout.Set("batchId", "")
+			}
+			if err := txDao.SaveRecord(payout); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PocketPayoutsRepo) GetOwnerPayouts(ownerId string) ([]my_models.Payout, error) {
+	logger.Info("Repo: Getting payouts of owner ", ownerId)
+
+	var payouts []my_models.Payout
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE ownerId = {:ownerId} ORDER BY dueAt DESC", payoutsCollection)).
+		Bind(dbx.Params{"ownerId": ownerId}).
+		All(&payouts)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	if payouts == nil {
+		payouts = []my_models.Payout{}
+	}
+
+	logger.Info("Repo: Got payouts succesfully")
+	return payouts, nil
+}
+
+func (r *PocketPayoutsRepo) GetOwnerBatches(ownerId string) ([]my_models.PayoutBatch, error) {
+	logger.Info("Repo: Getting payout batches of owner ", ownerId)
+
+	var batches []my_models.PayoutBatch
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE ownerId = {:ownerId} ORDER BY created DESC", payoutBatchesCollection)).
+		Bind(dbx.Params{"ownerId": ownerId}).
+		All(&batches)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	if batches == nil {
+		batches = []my_models.PayoutBatch{}
+	}
+
+	logger.Info("Repo: Got payout batches succesfully")
+	return batches, nil
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+const (
+	priceRulesCollection = "price_rules"
+)
+
+type PocketPriceRulesRepo struct {
+	Db pocketbase.PocketBase
+}
+
+func (r *PocketPriceRulesRepo) AddPriceRule(propertyId string, rule my_models.PriceRule) (string, error) {
+	logger.Info("Repo: Adding price rule for property ", propertyId)
+	collection, err := r.Db.Dao().FindCollectionByNameOrId(priceRulesCollection)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return "", err
+	}
+
+	record := models.NewRecord(collection)
+	form := forms.NewRecordUpsert(r.Db, record)
+	form.LoadData(rule.ToMap(propertyId))
+
+	if err := form.Submit(); err != nil {
+		logger.Error("Repo: ", err)
+		return "", err
+	}
+
+	logger.Info("Repo: Price rule added with id ", record.Id)
+	return record.Id, nil
+}
+
+func (r *PocketPriceRulesRepo) GetPriceRules(propertyId string) ([]my_models.PriceRule, error) {
+	logger.Info("Repo: Getting price rules for property ", propertyId)
+
+	var ruleDBOs []my_models.PriceRuleDBO
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT * FROM %s WHERE propertyId = {:propertyId} ORDER BY created", priceRulesCollection)).
+		Bind(dbx.Params{"propertyId": propertyId}).
+		All(&ruleDBOs)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	rules := make([]my_models.PriceRule, 0, len(ruleDBOs))
+	for _, dbo := range ruleDBOs {
+		rules = append(rules, dbo.ToObject())
+	}
+
+	logger.Info("Repo: Got price rules succesfully")
+	return rules, nil
+}
+
+func (r *PocketPriceRulesRepo) RemovePriceRule(propertyId string, ruleId string) error {
+	logger.Info("Repo: Removing price rule ", ruleId)
+
+	record, err := r.Db.Dao().FindRecordById(priceRulesCollection, ruleId)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return fmt.Errorf("price rule %s not found", ruleId)
+	}
+
+	if record.GetString("propertyId") != propertyId {
+		logger.Error("Repo: price rule does not belong to property ", propertyId)
+		return fmt.Errorf("price rule %s does not belong to property %s", ruleId, propertyId)
+	}
+
+	if err := r.Db.Dao().DeleteRecord(record); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	logger.Info("Repo: Price rule removed succesfully")
+	return nil
+}
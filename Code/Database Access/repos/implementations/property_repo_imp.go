@@ -300,7 +300,7 @@ func (r *PocketPropertyRepo) GetFilteredProperties(filter my_models.PropertyFilt
 		query += fmt.Sprintf(`
 			SELECT property
 			FROM reservations
-			WHERE (status = 'Approved' OR status = 'Paid')
+			WHERE status IN ('Approved', 'PartiallyPaid', 'Paid')
 			AND NOT (
 				reserved_until <= '%s'
 				OR reserved_from >= '%s'
@@ -320,6 +320,16 @@ func (r *PocketPropertyRepo) GetFilteredProperties(filter my_models.PropertyFilt
 		query += ")"
 	}
 
+	// Booking rules only apply when the tenant searches for a specific stay
+	if filter.DateFrom != nil && filter.DateTo != nil {
+		rulesQuery, err := bookingRulesQuery(startDate, endDate, time.Now())
+		if err != nil {
+			logger.Error("Repo: ", err)
+			return nil, err
+		}
+		query += rulesQuery
+	}
+
 	quantity := *filter.Size
 	offset := ((*filter.Page - 1) * quantity)
 	query += fmt.Sprintf(` LIMIT %d OFFSET %d`, quantity, offset)
@@ -352,6 +362,37 @@ func (r *PocketPropertyRepo) GetFilteredProperties(filter my_models.PropertyFilt
 	return newProperties, nil
 }
 
+// bookingRulesQuery filters out properties whose booking rules reject a stay
+// from startDate to endDate booked today. Properties without rules are kept.
+func bookingRulesQuery(startDate string, endDate string, today time.Time) (string, error) {
+	fromDate, err := time.Parse(time.DateOnly, startDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid dateFrom %s, expected YYYY-MM-DD", startDate)
+	}
+	untilDate, err := time.Parse(time.DateOnly, endDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid dateTo %s, expected YYYY-MM-DD", endDate)
+	}
+
+	nights := my_models.DaysBetween(fromDate, untilDate)
+	daysUntilCheckIn := my_models.DaysBetween(today, fromDate)
+	rule := func(name string) string {
+		return fmt.Sprintf(`IFNULL(CASE WHEN json_valid(bookingRules) THEN json_extract(bookingRules, '$.%s') END, 0)`, name)
+	}
+
+	query := fmt.Sprintf(` AND %s <= %d`, rule("minNights"), nights)
+	query += fmt.Sprintf(` AND (%s = 0 OR %s >= %d)`, rule("maxNights"), rule("maxNights"), nights)
+	query += fmt.Sprintf(` AND %s <= %d`, rule("advanceNoticeDays"), daysUntilCheckIn)
+	query += fmt.Sprintf(` AND (%s = 0 OR %s >= %d)`, rule("bookingWindowDays"), rule("bookingWindowDays"), daysUntilCheckIn)
+	query += fmt.Sprintf(` AND (CASE
+			WHEN json_valid(bookingRules) AND json_type(bookingRules, '$.checkInDays') = 'array' AND json_array_length(bookingRules, '$.checkInDays') > 0
+			THEN EXISTS (SELECT 1 FROM json_each(bookingRules, '$.checkInDays') WHERE value = %d)
+			ELSE 1
+		END)`, int(fromDate.Weekday()))
+
+	return query, nil
+}
+
 func (r *PocketPropertyRepo) GetUnavailableDates(propertyId string) ([]my_models.DateRange, error) {
 	logger.Info("Repo: Getting unavailable dates")
 
@@ -639,60 +680,124 @@ func (r *PocketPropertyRepo) UpdatePropertyPendingPaymentStatus(id string, statu
 	return nil
 }
 
-func (r *PocketPropertyRepo) AddPropertyImage(id string, image multipart.File, fileExtension string) error {
-	logger.Info("Repo: Adding image to property with id: ", id)
-	collection, err := r.Db.Dao().FindCollectionByNameOrId("images")
+func (r *PocketPropertyRepo) UpdateInstantBook(id string, instantBook bool, rules my_models.InstantBookRules) error {
+	logger.Info("Repo: Updating instant book settings for property ", id)
+	record, err := r.Db.Dao().FindRecordById(propertiesCollection, id)
 	if err != nil {
-		logger.Error("Repo: Error finding images collection:", err)
+		logger.Error("Repo: ", err)
 		return err
 	}
 
-	// Create a new record in the images collection
-	record := models.NewRecord(collection)
-	form := forms.NewRecordUpsert(r.Db, record)
+	record.Set("instantBook", instantBook)
+	record.Set("instantBookRules", rules)
 
-	// Ensure the "images" directory exists
-	if err := os.MkdirAll(r.imagesDir, os.ModePerm); err != nil {
-		logger.Error("Repo: Error creating images directory:", err)
+	if err := r.Db.Dao().SaveRecord(record); err != nil {
+		logger.Error("Repo: ", err)
 		return err
 	}
 
-	// Generate a random name for the temporary file
-	randomFileName := fmt.Sprintf("%d%s.tmp", time.Now().UnixNano(), fileExtension)
-	tempFilePath := filepath.Join(r.imagesDir, randomFileName)
-	tempFile, err := os.Create(tempFilePath)
+	if r.Cache != nil {
+		if err := r.Cache.Del(ctx, id).Err(); err != nil {
+			logger.Warn("Repo: Error deleting property from cache: ", err)
+		}
+	}
+
+	logger.Info("Repo: Instant book settings updated successfully")
+	return nil
+}
+
+func (r *PocketPropertyRepo) UpdateBookingRules(id string, rules my_models.BookingRules) error {
+	logger.Info("Repo: Updating booking rules for property ", id)
+	record, err := r.Db.Dao().FindRecordById(propertiesCollection, id)
 	if err != nil {
-		logger.Error("Repo: Error creating temporary image file:", err)
+		logger.Error("Repo: ", err)
 		return err
 	}
-	defer tempFile.Close()
 
-	// Copy the image data to the temporary file
-	_, err = io.Copy(tempFile, image)
+	record.Set("bookingRules", rules)
+
+	if err := r.Db.Dao().SaveRecord(record); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	if r.Cache != nil {
+		if err := r.Cache.Del(ctx, id).Err(); err != nil {
+			logger.Warn("Repo: Error deleting property from cache: ", err)
+		}
+	}
+
+	logger.Info("Repo: Booking rules updated successfully")
+	return nil
+}
+
+func (r *PocketPropertyRepo) UpdateCancellationPolicy(id string, policy *my_models.CancellationPolicy) error {
+	logger.Info("Repo: Updating cancellation policy for property ", id)
+	record, err := r.Db.Dao().FindRecordById(propertiesCollection, id)
 	if err != nil {
-		logger.Error("Repo: Error copying image data to temporary file: ", err)
+		logger.Error("Repo: ", err)
 		return err
 	}
 
-	// Close the temporary file before processing with ffmpeg
-	tempFile.Close()
+	record.Set("cancellationPolicy", policy)
 
-	// Generate a random name for the final file
-	finalFileName := fmt.Sprintf("%d%s", time.Now().UnixNano(), fileExtension)
-	finalFilePath := filepath.Join(r.imagesDir, finalFileName)
+	if err := r.Db.Dao().SaveRecord(record); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
 
-	// Run ffmpeg to resize the image from the temporary file to the final file
-	err = ffmpeg.Input(tempFilePath).
-		Output(finalFilePath, ffmpeg.KwArgs{"vf": r.imagesCompressionScale}).
-		OverWriteOutput().ErrorToStdOut().Run()
+	if r.Cache != nil {
+		if err := r.Cache.Del(ctx, id).Err(); err != nil {
+			logger.Warn("Repo: Error deleting property from cache: ", err)
+		}
+	}
+
+	logger.Info("Repo: Cancellation policy updated successfully")
+	return nil
+}
+
+func (r *PocketPropertyRepo) UpdatePaymentTerms(id string, terms my_models.PaymentTerms) error {
+	logger.Info("Repo: Updating payment terms for property ", id)
+	record, err := r.Db.Dao().FindRecordById(propertiesCollection, id)
 	if err != nil {
-		logger.Error("Repo: Error resizing image:", err)
-		os.Remove(tempFilePath) // Remove the temporary file
+		logger.Error("Repo: ", err)
 		return err
 	}
 
-	// Remove the temporary file
-	os.Remove(tempFilePath)
+	record.Set("paymentTerms", terms)
+
+	if err := r.Db.Dao().SaveRecord(record); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	if r.Cache != nil {
+		if err := r.Cache.Del(ctx, id).Err(); err != nil {
+			logger.Warn("Repo: Error deleting property from cache: ", err)
+		}
+	}
+
+	logger.Info("Repo: Payment terms updated successfully")
+	return nil
+}
+
+func (r *PocketPropertyRepo) AddPropertyImage(id string, image multipart.File, fileExtension string) error {
+	logger.Info("Repo: Adding image to property with id: ", id)
+	collection, err := r.Db.Dao().FindCollectionByNameOrId("images")
+	if err != nil {
+		logger.Error("Repo: Error finding images collection:", err)
+		return err
+	}
+
+	// Create a new record in the images collection
+	record := models.NewRecord(collection)
+	form := forms.NewRecordUpsert(r.Db, record)
+
+	finalFileName, err := r.StoreImage(image, fileExtension)
+	if err != nil {
+		return err
+	}
+	finalFilePath := filepath.Join(r.imagesDir, finalFileName)
 
 	// Load data into the form
 	form.LoadData(map[string]interface{}{
@@ -729,3 +834,57 @@ func (r *PocketPropertyRepo) AddPropertyImage(id string, image multipart.File, f
 	logger.Info("Repo: Image added to property with id: ", id)
 	return nil
 }
+
+// StoreImage resizes the image into the images directory and returns the name
+// of the stored file.
+func (r *PocketPropertyRepo) StoreImage(image multipart.File, fileExtension string) (string, error) {
+	// Ensure the "images" directory exists
+	if err := os.MkdirAll(r.imagesDir, os.ModePerm); err != nil {
+		logger.Error("Repo: Error creating images directory:", err)
+		return "", err
+	}
+
+	// Generate a random name for the temporary file
+	randomFileName := fmt.Sprintf("%d%s.tmp", time.Now().UnixNano(), fileExtension)
+	tempFilePath := filepath.Join(r.imagesDir, randomFileName)
+	tempFile, err := os.Create(tempFilePath)
+	if err != nil {
+		logger.Error("Repo: Error creating temporary image file:", err)
+		return "", err
+	}
+	defer tempFile.Close()
+
+	// Copy the image data to the temporary file
+	_, err = io.Copy(tempFile, image)
+	if err != nil {
+		logger.Error("Repo: Error copying image data to temporary file: ", err)
+		os.Remove(tempFilePath)
+		return "", err
+	}
+
+	// Close the temporary file before processing with ffmpeg
+	tempFile.Close()
+
+	// Generate a random name for the final file
+	finalFileName := fmt.Sprintf("%d%s", time.Now().UnixNano(), fileExtension)
+	finalFilePath := filepath.Join(r.imagesDir, finalFileName)
+
+	// Run ffmpeg to resize the image from the temporary file to the final file
+	err = ffmpeg.Input(tempFilePath).
+		Output(finalFilePath, ffmpeg.KwArgs{"vf": r.imagesCompressionScale}).
+		OverWriteOutput().ErrorToStdOut().Run()
+	if err != nil {
+		logger.Error("Repo: Error resizing image:", err)
+		os.Remove(tempFilePath) // Remove the temporary file
+		return "", err
+	}
+
+	// Remove the temporary file
+	os.Remove(tempFilePath)
+
+	return finalFileName, nil
+}
+
+func (r *PocketPropertyRepo) GetImageUrl(fileName string) string {
+	return fmt.Sprintf(r.imagesUrl, fileName)
+}
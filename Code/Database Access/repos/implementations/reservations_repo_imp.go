@@ -6,6 +6,7 @@ import (
 	"pocketbase_go/my_models"
 	"time"
 
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/forms"
 	"github.com/pocketbase/pocketbase/models"
@@ -23,18 +24,18 @@ type PocketReservationRepo struct {
 	Db pocketbase.PocketBase
 }
 
-func (r *PocketReservationRepo) CreateReservation(reservation my_models.ReservationModel) error {
+func (r *PocketReservationRepo) CreateReservation(reservation my_models.ReservationModel) (string, error) {
 	logger.Info("Repo: Creating reservation")
 	reservationsCollection, err := r.Db.Dao().FindCollectionByNameOrId(reservationsCollectionName)
 	if err != nil {
 		logger.Error("Repo: ", err)
-		return err
+		return "", err
 	}
 
 	propertyRecord, err := r.Db.Dao().FindRecordById(propertiesCollectionName, reservation.PropertyId)
 	if err != nil {
 		logger.Error("Repo: ", err)
-		return err
+		return "", err
 	}
 
 	var unavailableDates []my_models.DateRange
@@ -44,17 +45,17 @@ func (r *PocketReservationRepo) CreateReservation(reservation my_models.Reservat
 
 	if reservation.Adults > propertyAdultQuantity || reservation.Minors > propertyKidQuantity {
 		logger.Error("Repo: property does not have enough capacity for the given number of tenants")
-		return fmt.Errorf("property does not have enough capacity for the given number of tenants")
+		return "", fmt.Errorf("property does not have enough capacity for the given number of tenants")
 	}
 
 	if err := _checkExistingReservations(reservation, r.Db); err != nil {
 		logger.Error("Repo: ", err)
-		return err
+		return "", err
 	}
 
 	if err := _checkPropertyAvailableDates(reservation, unavailableDates); err != nil {
 		logger.Error("Repo: ", err)
-		return err
+		return "", err
 	}
 
 	record := models.NewRecord(reservationsCollection)
@@ -67,11 +68,11 @@ func (r *PocketReservationRepo) CreateReservation(reservation my_models.Reservat
 
 	if err := form.Submit(); err != nil {
 		logger.Error("Repo: ", err)
-		return err
+		return "", err
 	}
 
 	logger.Info("Repo: Reservation created succesfully")
-	return nil
+	return record.Id, nil
 }
 
 func _checkExistingReservations(reservation my_models.ReservationModel, db pocketbase.PocketBase) error {
@@ -79,7 +80,7 @@ func _checkExistingReservations(reservation my_models.ReservationModel, db pocke
 		SELECT *
 		FROM %s
 		WHERE property = '%s'
-		AND status = 'Approved'
+		AND status IN ('Approved', 'PartiallyPaid', 'Paid')
 		`, reservationsCollectionName, reservation.PropertyId)
 
 	var existingreservations []my_models.ReservationModel
@@ -153,6 +154,62 @@ func (r *PocketReservationRepo) ApproveReservation(reservationId string) error {
 	return nil
 }
 
+func (r *PocketReservationRepo) RejectReservation(reservationId string, reason string) error {
+	logger.Info("Repo: Rejecting reservation")
+
+	record, err := r.Db.Dao().FindRecordById(reservationsCollectionName, reservationId)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	if record.GetString("status") != "Pending" {
+		logger.Error("Repo: reservation is not pending")
+		return fmt.Errorf("only pending reservations can be rejected")
+	}
+
+	record.Set("status", "Rejected")
+	record.Set("rejection_reason", reason)
+	err = r.Db.Dao().SaveRecord(record)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	logger.Info("Repo: Reservation rejected succesfully")
+	return nil
+}
+
+// CancelReservationWithReason cancels an approved or paid reservation on behalf
+// of cancelledBy, keeping the reason for the tenant and the reports.
+func (r *PocketReservationRepo) CancelReservationWithReason(reservationId string, reason string, cancelledBy string) error {
+	logger.Info("Repo: Cancelling reservation on behalf of ", cancelledBy)
+
+	record, err := r.Db.Dao().FindRecordById(reservationsCollectionName, reservationId)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	currentStatus := record.GetString("status")
+	if currentStatus != "Approved" && currentStatus != "PartiallyPaid" && currentStatus != "Paid" {
+		logger.Error("Repo: reservation cannot be cancelled with status ", currentStatus)
+		return fmt.Errorf("only approved or paid reservations can be cancelled, reservation is %s", currentStatus)
+	}
+
+	record.Set("status", "Cancelled")
+	record.Set("cancellation_reason", reason)
+	record.Set("cancelled_by", cancelledBy)
+	err = r.Db.Dao().SaveRecord(record)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	logger.Info("Repo: Reservation cancelled succesfully")
+	return nil
+}
+
 func _createDateRange(from string, until string, dateLayout string) (dr.DateRange, error) {
 	fromDate, err := time.Parse(dateLayout, from)
 	if err != nil {
@@ -169,55 +226,89 @@ func _createDateRange(from string, until string, dateLayout string) (dr.DateRang
 	return myDateRange, nil
 }
 
-func (r *PocketReservationRepo) GetFilteredReservations(filter my_models.ReservationFilter) ([]my_models.ReservationModel, error) {
+// GetFilteredReservations returns the matching reservations and how many there are in total.
+// Results are only paginated when both page and size are set in the filter.
+func (r *PocketReservationRepo) GetFilteredReservations(filter my_models.ReservationFilter) ([]my_models.ReservationModel, int, error) {
 	logger.Info("Repo: Getting filtered reservations")
 
-	// Start building the SQL query
-	query := fmt.Sprintf(`
-		SELECT *
-		FROM %s
-		WHERE 1 = 1
-		`, reservationsCollectionName)
+	orderBy, err := filter.OrderBy()
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, 0, err
+	}
+
+	// Build the conditions based on filter values
+	conditions := "1 = 1"
+	params := dbx.Params{}
 
-	// Add conditions based on filter values
 	if filter.ReservedFrom != nil {
-		query += fmt.Sprintf(` AND reserved_from >= %s`, *filter.ReservedFrom)
+		conditions += ` AND reserved_from >= {:reservedFrom}`
+		params["reservedFrom"] = *filter.ReservedFrom
 	}
 
 	if filter.ReservedUntil != nil {
-		query += fmt.Sprintf(` AND reserved_until <= %s`, *filter.ReservedUntil)
+		conditions += ` AND reserved_until <= {:reservedUntil}`
+		params["reservedUntil"] = *filter.ReservedUntil
 	}
 
 	if filter.Status != nil {
-		query += fmt.Sprintf(` AND status = '%s'`, *filter.Status)
+		conditions += ` AND status = {:status}`
+		params["status"] = *filter.Status
 	}
 
 	if filter.PropertyId != nil {
-		query += fmt.Sprintf(` AND property = '%s'`, *filter.PropertyId)
+		conditions += ` AND property = {:propertyId}`
+		params["propertyId"] = *filter.PropertyId
 	}
 
 	if filter.TenantEmail != nil {
-		query += fmt.Sprintf(` AND email = '%s'`, *filter.TenantEmail)
+		conditions += ` AND email = {:email}`
+		params["email"] = *filter.TenantEmail
 	}
 
 	if filter.TenantName != nil {
-		query += fmt.Sprintf(` AND name = '%s'`, *filter.TenantName)
+		conditions += ` AND name = {:name}`
+		params["name"] = *filter.TenantName
 	}
 
 	if filter.TenantLastName != nil {
-		query += fmt.Sprintf(` AND lastName = '%s'`, *filter.TenantLastName)
+		conditions += ` AND last_name = {:lastName}`
+		params["lastName"] = *filter.TenantLastName
+	}
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	err = r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT COUNT(*) AS count FROM %s WHERE %s", reservationsCollectionName, conditions)).
+		Bind(params).
+		One(&result)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT *
+		FROM %s
+		WHERE %s
+		ORDER BY %s
+		`, reservationsCollectionName, conditions, orderBy)
+
+	if filter.Page != nil && filter.Size != nil {
+		query += fmt.Sprintf(` LIMIT %d OFFSET %d`, *filter.Size, (*filter.Page-1)*(*filter.Size))
 	}
 
 	// Execute the query
 	var reservations []my_models.ReservationModel
-	err := r.Db.Dao().DB().NewQuery(query).All(&reservations)
+	err = r.Db.Dao().DB().NewQuery(query).Bind(params).All(&reservations)
 	if err != nil {
 		logger.Error("Repo: ", err)
-		return nil, err
+		return nil, 0, err
 	}
 
 	logger.Info("Repo: Got filtered reservations succesfully")
-	return reservations, nil
+	return reservations, result.Count, nil
 }
 
 func (r *PocketReservationRepo) GetOwnReservation(email string, propertyId string) (my_models.ReservationModel, error) {
@@ -240,6 +331,53 @@ func (r *PocketReservationRepo) GetOwnReservation(email string, propertyId strin
 	return reservation, nil
 }
 
+func (r *PocketReservationRepo) GetTenantReservations(email string, filter my_models.TenantReservationFilter) ([]my_models.ReservationModel, int, error) {
+	logger.Info("Repo: Getting reservations of tenant ", email)
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	conditions := "email = {:email}"
+	switch filter.Scope {
+	case my_models.UpcomingReservations:
+		conditions += " AND status NOT IN ('Cancelled', 'Rejected', 'Expired') AND reserved_until >= {:today} AND check_out = ''"
+	case my_models.PastReservations:
+		conditions += " AND status NOT IN ('Cancelled', 'Rejected', 'Expired') AND (reserved_until < {:today} OR check_out != '')"
+	case my_models.CancelledReservations:
+		conditions += " AND status IN ('Cancelled', 'Rejected', 'Expired')"
+	}
+	params := dbx.Params{"email": email, "today": today}
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("SELECT COUNT(*) AS count FROM %s WHERE %s", reservationsCollectionName, conditions)).
+		Bind(params).
+		One(&result)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, 0, err
+	}
+
+	var reservations []my_models.ReservationModel
+	err = r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			SELECT *
+			FROM %s
+			WHERE %s
+			ORDER BY reserved_from DESC
+			LIMIT %d OFFSET %d
+			`, reservationsCollectionName, conditions, filter.Size, (filter.Page-1)*filter.Size)).
+		Bind(params).
+		All(&reservations)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, 0, err
+	}
+
+	logger.Info("Repo: Got reservations of tenant succesfully")
+	return reservations, result.Count, nil
+}
+
 func (r *PocketReservationRepo) RemoveReservation(reservationId string) error {
 	logger.Info("Repo: Removing reservation")
 
@@ -375,6 +513,138 @@ func (r *PocketReservationRepo) RegisterCheckOut(reservationId string) error {
 	return nil
 }
 
+func (r *PocketReservationRepo) ExpirePendingReservations(slaHours int) ([]my_models.ReservationModel, error) {
+	logger.Info("Repo: Expiring pending reservations")
+	expirationDate := time.Now().UTC().Add(-time.Duration(slaHours) * time.Hour)
+
+	var reservations []my_models.ReservationModel
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			SELECT *
+			FROM %s
+			WHERE status = 'Pending'
+			AND created < {:expiration}
+			`, reservationsCollectionName)).
+		Bind(dbx.Params{"expiration": expirationDate.Format(my_models.PocketTimeLayout)}).
+		All(&reservations)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	for i, reservation := range reservations {
+		if err := r.UpdateReservationStatus(reservation.ID, "Expired"); err != nil {
+			logger.Error("Repo: ", err)
+			return nil, err
+		}
+		reservations[i].Status = "Expired"
+	}
+
+	logger.Info("Repo: Expired pending reservations succesfully")
+	return reservations, nil
+}
+
+// MarkNoShowReservations flags paid reservations whose tenant has not checked in
+// cutoffHours after the stay started.
+func (r *PocketReservationRepo) MarkNoShowReservations(cutoffHours int) ([]my_models.ReservationModel, error) {
+	logger.Info("Repo: Marking no-show reservations")
+	cutoffDate := time.Now().UTC().Add(-time.Duration(cutoffHours) * time.Hour)
+
+	var reservations []my_models.ReservationModel
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			SELECT *
+			FROM %s
+			WHERE status IN ('PartiallyPaid', 'Paid')
+			AND check_in = ''
+			AND reserved_from < {:cutoff}
+			`, reservationsCollectionName)).
+		Bind(dbx.Params{"cutoff": cutoffDate.Format(my_models.PocketTimeLayout)}).
+		All(&reservations)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	for i, reservation := range reservations {
+		if err := r.UpdateReservationStatus(reservation.ID, "NoShow"); err != nil {
+			logger.Error("Repo: ", err)
+			return nil, err
+		}
+		reservations[i].Status = "NoShow"
+	}
+
+	logger.Info("Repo: Marked no-show reservations succesfully")
+	return reservations, nil
+}
+
+// AutoCheckOutReservations checks out the stays that are past their end date
+// without a check out and flags them so someone reviews the property.
+func (r *PocketReservationRepo) AutoCheckOutReservations() ([]my_models.ReservationModel, error) {
+	logger.Info("Repo: Checking out overdue reservations")
+	now := time.Now().UTC()
+
+	var reservations []my_models.ReservationModel
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			SELECT *
+			FROM %s
+			WHERE check_in != ''
+			AND check_out = ''
+			AND status NOT IN ('Cancelled', 'NoShow')
+			AND reserved_until < {:now}
+			`, reservationsCollectionName)).
+		Bind(dbx.Params{"now": now.Format(my_models.PocketTimeLayout)}).
+		All(&reservations)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	for i, reservation := range reservations {
+		record, err := r.Db.Dao().FindRecordById(reservationsCollectionName, reservation.ID)
+		if err != nil {
+			logger.Error("Repo: ", err)
+			return nil, err
+		}
+
+		record.Set("check_out", now)
+		record.Set("needs_review", true)
+		if err := r.Db.Dao().SaveRecord(record); err != nil {
+			logger.Error("Repo: ", err)
+			return nil, err
+		}
+		reservations[i].CheckOut = now.Format(my_models.PocketTimeLayout)
+		reservations[i].NeedsReview = true
+	}
+
+	logger.Info("Repo: Checked out overdue reservations succesfully")
+	return reservations, nil
+}
+
+func (r *PocketReservationRepo) CountCompletedStays(email string) (int, error) {
+	logger.Info("Repo: Counting completed stays for ", email)
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			SELECT COUNT(*) AS count
+			FROM %s
+			WHERE email = {:email}
+			AND check_out != ''
+			`, reservationsCollectionName)).
+		Bind(dbx.Params{"email": email}).
+		One(&result)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return 0, err
+	}
+
+	return result.Count, nil
+}
+
 func (r *PocketReservationRepo) UpdateReservationStatus(id string, status string) error {
 	logger.Info("Repo: Updating reservation status")
 	record, err := r.Db.Dao().FindRecordById("reservations", id)
@@ -394,17 +664,15 @@ func (r *PocketReservationRepo) UpdateReservationStatus(id string, status string
 	return nil
 }
 
-func (r *PocketReservationRepo) AutoCancelReservations(autoCancelDays int) ([]string, error) {
-	logger.Info("Repo: Auto cancelling reservations")
-	today := time.Now()
-	expirationDate := today.AddDate(0, 0, -autoCancelDays)
+func (r *PocketReservationRepo) GetUnpaidApprovedReservations() ([]my_models.ReservationModel, error) {
+	logger.Info("Repo: Getting unpaid approved reservations")
 
 	query := fmt.Sprintf(`
 		SELECT *
 		FROM %s
 		WHERE status = 'Approved'
-		AND approved_date < '%s'
-		`, reservationsCollectionName, expirationDate.Format(time.DateOnly))
+		AND approved_date != ''
+		`, reservationsCollectionName)
 
 	var reservations []my_models.ReservationModel
 	err := r.Db.Dao().DB().NewQuery(query).All(&reservations)
@@ -413,16 +681,6 @@ func (r *PocketReservationRepo) AutoCancelReservations(autoCancelDays int) ([]st
 		return nil, err
 	}
 
-	propertiesIds := []string{}
-	for _, reservation := range reservations {
-		if err := r.CancelReservation(reservation.ID); err != nil {
-			logger.Error("Repo: ", err)
-			return nil, err
-		}
-		propertiesIds = append(propertiesIds, reservation.PropertyId)
-		logger.Info("Notification: Sending email to Tenant ", reservation.Email, " about reservation being canceled :", reservation.ID)
-	}
-
-	logger.Info("Repo: Auto cancelled reservations succesfully")
-	return propertiesIds, nil
+	logger.Info("Repo: Got unpaid approved reservations succesfully")
+	return reservations, nil
 }
@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+const (
+	scheduledNotificationsCollection = "scheduled_notifications"
+)
+
+type PocketScheduledNotificationsRepo struct {
+	Db pocketbase.PocketBase
+}
+
+// Schedule stores the notification, or reschedules the pending one with the same
+// dedupe key. Notifications that were already sent are left untouched.
+func (r *PocketScheduledNotificationsRepo) Schedule(notification my_models.ScheduledNotification) error {
+	logger.Info("Repo: Scheduling notification ", notification.DedupeKey)
+
+	record, err := r.Db.Dao().FindFirstRecordByData(scheduledNotificationsCollection, "dedupeKey", notification.DedupeKey)
+	if err == nil {
+		if record.GetString("sentAt") != "" {
+			logger.Info("Repo: Notification ", notification.DedupeKey, " was already sent")
+			return nil
+		}
+	} else {
+		collection, err := r.Db.Dao().FindCollectionByNameOrId(scheduledNotificationsCollection)
+		if err != nil {
+			logger.Error("Repo: ", err)
+			return err
+		}
+		record = models.NewRecord(collection)
+	}
+
+	form := forms.NewRecordUpsert(r.Db, record)
+	form.LoadData(notification.ToMap())
+	if err := form.Submit(); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	logger.Info("Repo: Notification scheduled with id ", record.Id)
+	return nil
+}
+
+func (r *PocketScheduledNotificationsRepo) GetDueNotifications(now time.Time, maxAttempts int, limit int) ([]my_models.ScheduledNotification, error) {
+	logger.Info("Repo: Getting due notifications")
+
+	var notifications []my_models.ScheduledNotification
+	err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf(`
+			SELECT *
+			FROM %s
+			WHERE sentAt = ''
+			AND dueAt <= {:now}
+			AND attempts < {:maxAttempts}
+			ORDER BY dueAt
+			LIMIT {:limit}
+			`, scheduledNotificationsCollection)).
+		Bind(dbx.Params{"now": now.UTC().Format(my_models.PocketTimeLayout), "maxAttempts": maxAttempts, "limit": limit}).
+		All(&notifications)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return nil, err
+	}
+
+	logger.Info("Repo: Got due notifications succesfully")
+	return notifications, nil
+}
+
+func (r *PocketScheduledNotificationsRepo) MarkSent(id string) error {
+	record, err := r.Db.Dao().FindRecordById(scheduledNotificationsCollection, id)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	record.Set("sentAt", time.Now())
+	record.Set("attempts", record.GetInt("attempts")+1)
+	if err := r.Db.Dao().SaveRecord(record); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PocketScheduledNotificationsRepo) MarkFailed(id string, reason string) error {
+	record, err := r.Db.Dao().FindRecordById(scheduledNotificationsCollection, id)
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	record.Set("attempts", record.GetInt("attempts")+1)
+	record.Set("lastError", reason)
+	if err := r.Db.Dao().SaveRecord(record); err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeletePendingForReservation drops the reminders that were not sent yet, used
+// when the reservation is cancelled or otherwise closed.
+func (r *PocketScheduledNotificationsRepo) DeletePendingForReservation(reservationId string) error {
+	logger.Info("Repo: Deleting pending notifications of reservation ", reservationId)
+
+	_, err := r.Db.Dao().DB().
+		NewQuery(fmt.Sprintf("DELETE FROM %s WHERE reservationId = {:reservationId} AND sentAt = ''", scheduledNotificationsCollection)).
+		Bind(dbx.Params{"reservationId": reservationId}).
+		Execute()
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return err
+	}
+
+	return nil
+}
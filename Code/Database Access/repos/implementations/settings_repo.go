@@ -9,17 +9,20 @@ import (
 const (
 	cancellationSettings    = "cancellations_days_settings"
 	refundSettings          = "refund_percentage_settings"
+	paymentDeadlineSettings = "payment_deadline_settings"
 )
 
 type PocketSettingsRepo struct {
-	Db                      pocketbase.PocketBase
-	defaultRefundPercentage float64
-	defaultCancellationDays int
+	Db                          pocketbase.PocketBase
+	defaultRefundPercentage     float64
+	defaultCancellationDays     int
+	defaultPaymentDeadlineHours int
 }
 
-func (s *PocketSettingsRepo) SetConfigValues(defaultRefundPercentage float64, defaultCancellationDays int) {
+func (s *PocketSettingsRepo) SetConfigValues(defaultRefundPercentage float64, defaultCancellationDays int, defaultPaymentDeadlineHours int) {
 	s.defaultCancellationDays = defaultCancellationDays
 	s.defaultRefundPercentage = defaultRefundPercentage
+	s.defaultPaymentDeadlineHours = defaultPaymentDeadlineHours
 }
 
 func (s *PocketSettingsRepo) GetCancellationDays(countryCode string) (days int, err error) {
@@ -55,3 +58,20 @@ func (s *PocketSettingsRepo) GetRefundPercentage(countryCode string) (percentage
 	logger.Info("Repo: Refund percentage found for country: ", countryCode)
 	return record.GetFloat("value"), nil
 }
+
+func (s *PocketSettingsRepo) GetPaymentDeadlineHours(countryCode string) (hours int, err error) {
+	logger.Info("Repo: Getting payment deadline for country: ", countryCode)
+	record, err := s.Db.Dao().FindFirstRecordByData(paymentDeadlineSettings, "country", countryCode)
+	if err != nil && err.Error() == "sql: no rows in result set" {
+		logger.Warn("Repo: No payment deadline found for country: ", countryCode)
+		return s.defaultPaymentDeadlineHours, nil
+	}
+
+	if err != nil {
+		logger.Error("Repo: ", err)
+		return 0, err
+	}
+
+	logger.Info("Repo: Payment deadline found for country: ", countryCode)
+	return record.GetInt("hours"), nil
+}
@@ -0,0 +1,10 @@
+package repointerfaces
+
+import (
+	"pocketbase_go/my_models"
+)
+
+type IGuestsRepo interface {
+	GetGuests(reservationId string) ([]my_models.Guest, error)
+	ReplaceGuests(reservationId string, guests []my_models.Guest) error
+}
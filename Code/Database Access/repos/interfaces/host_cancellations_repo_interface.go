@@ -0,0 +1,11 @@
+package repointerfaces
+
+import (
+	"pocketbase_go/my_models"
+	"time"
+)
+
+type IHostCancellationsRepo interface {
+	AddHostCancellation(cancellation my_models.HostCancellation) error
+	GetFrequentCancellers(fromDate time.Time, untilDate time.Time, minCancellations int) ([]my_models.HostCancellationsReportItem, error)
+}
@@ -0,0 +1,11 @@
+package repointerfaces
+
+import (
+	"pocketbase_go/my_models"
+)
+
+type IIdempotencyRepo interface {
+	Reserve(record my_models.IdempotencyRecord) (*my_models.IdempotencyRecord, error)
+	Complete(record my_models.IdempotencyRecord) error
+	Release(key string) error
+}
@@ -0,0 +1,11 @@
+package repointerfaces
+
+import (
+	"pocketbase_go/my_models"
+)
+
+type IMessagesRepo interface {
+	GetMessages(reservationId string) ([]my_models.Message, error)
+	AddMessage(message my_models.Message) (my_models.Message, error)
+	MarkMessagesRead(reservationId string, readerRole string) (int64, error)
+}
@@ -0,0 +1,10 @@
+package repointerfaces
+
+import (
+	"pocketbase_go/my_models"
+)
+
+type IPaymentEventsRepo interface {
+	AddEvent(event my_models.PaymentEvent) (bool, error)
+	RemoveEvent(eventId string) error
+}
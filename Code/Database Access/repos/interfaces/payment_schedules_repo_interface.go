@@ -0,0 +1,15 @@
+package repointerfaces
+
+import (
+	"pocketbase_go/my_models"
+	"time"
+)
+
+type IPaymentSchedulesRepo interface {
+	AddInstallments(reservationId string, installments []my_models.PaymentInstallment) error
+	GetInstallments(reservationId string) ([]my_models.PaymentInstallment, error)
+	GetDueBalances(now time.Time, maxAttempts int) ([]my_models.PaymentInstallment, error)
+	MarkInstallmentPaid(id string) error
+	MarkInstallmentFailed(id string, reason string, retryAt time.Time, final bool) error
+	CancelPendingInstallments(reservationId string) error
+}
@@ -0,0 +1,14 @@
+package repointerfaces
+
+import (
+	"pocketbase_go/my_models"
+)
+
+type IPaymentsRepo interface {
+	AddPayment(payment my_models.Payment) (my_models.Payment, error)
+	GetPaymentById(id string) (my_models.Payment, error)
+	GetPaymentByReference(gatewayReference string) (my_models.Payment, error)
+	UpdatePaymentResult(id string, status string, gatewayReference string, failureReason string) error
+	GetReservationPayments(reservationId string) ([]my_models.Payment, error)
+	GetPropertyPayments(propertyId string) ([]my_models.Payment, error)
+}
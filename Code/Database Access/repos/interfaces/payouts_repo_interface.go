@@ -0,0 +1,17 @@
+package repointerfaces
+
+import (
+	"pocketbase_go/my_models"
+	"time"
+)
+
+type IPayoutsRepo interface {
+	GetStaysAwaitingPayout() ([]my_models.ReservationModel, error)
+	AddPayout(payout my_models.Payout) (my_models.Payout, error)
+	GetDuePayouts(now time.Time) ([]my_models.Payout, error)
+	CancelPayout(id string) error
+	AddBatch(ownerId string, payouts []my_models.Payout) (my_models.PayoutBatch, error)
+	SettleBatch(batchId string, status string) error
+	GetOwnerPayouts(ownerId string) ([]my_models.Payout, error)
+	GetOwnerBatches(ownerId string) ([]my_models.PayoutBatch, error)
+}
@@ -0,0 +1,11 @@
+package repointerfaces
+
+import (
+	"pocketbase_go/my_models"
+)
+
+type IPriceRulesRepo interface {
+	AddPriceRule(propertyId string, rule my_models.PriceRule) (string, error)
+	GetPriceRules(propertyId string) ([]my_models.PriceRule, error)
+	RemovePriceRule(propertyId string, ruleId string) error
+}
@@ -18,4 +18,10 @@ type IPropertyRepo interface {
 	UpdatePropertyPaidStatus(id string) error
 	UpdatePropertyPendingPaymentStatus(id string, status bool) error
 	AddPropertyImage(id string, image multipart.File, fileExtension string) error
+	StoreImage(image multipart.File, fileExtension string) (string, error)
+	GetImageUrl(fileName string) string
+	UpdateInstantBook(id string, instantBook bool, rules my_models.InstantBookRules) error
+	UpdateBookingRules(id string, rules my_models.BookingRules) error
+	UpdateCancellationPolicy(id string, policy *my_models.CancellationPolicy) error
+	UpdatePaymentTerms(id string, terms my_models.PaymentTerms) error
 }
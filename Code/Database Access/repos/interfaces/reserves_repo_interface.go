@@ -5,15 +5,22 @@ import (
 )
 
 type IReservationRepo interface {
-	CreateReservation(reservation my_models.ReservationModel) error
+	CreateReservation(reservation my_models.ReservationModel) (string, error)
 	ApproveReservation(reservationId string) error
-	GetFilteredReservations(filter my_models.ReservationFilter) ([]my_models.ReservationModel, error)
+	GetFilteredReservations(filter my_models.ReservationFilter) ([]my_models.ReservationModel, int, error)
 	GetOwnReservation(email string, propertyId string) (my_models.ReservationModel, error)
 	CancelReservation(reservationId string) error
+	CancelReservationWithReason(reservationId string, reason string, cancelledBy string) error
 	RemoveReservation(reservationId string) error
 	GetReservationById(reservationId string) (my_models.ReservationModel, error)
 	RegisterCheckIn(reservationId string) error
 	RegisterCheckOut(reservationId string) error
 	UpdateReservationStatus(id string, status string) error
-	AutoCancelReservations(autoCancelDays int) ([]string, error)
+	GetUnpaidApprovedReservations() ([]my_models.ReservationModel, error)
+	CountCompletedStays(email string) (int, error)
+	RejectReservation(reservationId string, reason string) error
+	ExpirePendingReservations(slaHours int) ([]my_models.ReservationModel, error)
+	MarkNoShowReservations(cutoffHours int) ([]my_models.ReservationModel, error)
+	AutoCheckOutReservations() ([]my_models.ReservationModel, error)
+	GetTenantReservations(email string, filter my_models.TenantReservationFilter) ([]my_models.ReservationModel, int, error)
 }
\ No newline at end of file
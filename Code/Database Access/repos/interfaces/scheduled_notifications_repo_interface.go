@@ -0,0 +1,14 @@
+package repointerfaces
+
+import (
+	"pocketbase_go/my_models"
+	"time"
+)
+
+type IScheduledNotificationsRepo interface {
+	Schedule(notification my_models.ScheduledNotification) error
+	GetDueNotifications(now time.Time, maxAttempts int, limit int) ([]my_models.ScheduledNotification, error)
+	MarkSent(id string) error
+	MarkFailed(id string, reason string) error
+	DeletePendingForReservation(reservationId string) error
+}
@@ -3,4 +3,5 @@ package repointerfaces
 type ISettingsRepo interface {
 	GetCancellationDays(countryCode string) (int, error)
 	GetRefundPercentage(countryCode string) (float64, error)
+	GetPaymentDeadlineHours(countryCode string) (int, error)
 }
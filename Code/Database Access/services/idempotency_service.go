@@ -0,0 +1,57 @@
+package services
+
+import (
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/repos/interfaces"
+	"time"
+)
+
+const idempotencyKeyTTL = 24 * time.Hour
+
+type IdempotencyService struct {
+	Repo interfaces.IIdempotencyRepo
+}
+
+// Begin reserves the key for the request. It returns nil when the request has
+// to be processed, the stored record when it is a replay of a finished request,
+// and an error when the key is in use by another or an unfinished request.
+func (s *IdempotencyService) Begin(key string, requestHash string) (*my_models.IdempotencyRecord, error) {
+	stored, err := s.Repo.Reserve(my_models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      my_models.IdempotencyInProgress,
+		ExpiresAt:   time.Now().UTC().Add(idempotencyKeyTTL).Format(my_models.PocketTimeLayout),
+	})
+	if err != nil || stored == nil {
+		return nil, err
+	}
+
+	if stored.RequestHash != requestHash {
+		logger.Error("Service: Idempotency key reused with a different request")
+		return nil, my_models.ErrIdempotencyKeyReused
+	}
+
+	if stored.Status != my_models.IdempotencyCompleted {
+		return nil, my_models.ErrIdempotencyKeyInProgress
+	}
+
+	logger.Info("Service: Replaying response of idempotent request")
+	return stored, nil
+}
+
+func (s *IdempotencyService) Complete(key string, requestHash string, statusCode int, contentType string, body []byte) error {
+	return s.Repo.Complete(my_models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      my_models.IdempotencyCompleted,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        string(body),
+		ExpiresAt:   time.Now().UTC().Add(idempotencyKeyTTL).Format(my_models.PocketTimeLayout),
+	})
+}
+
+func (s *IdempotencyService) Release(key string) error {
+	return s.Repo.Release(key)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"pocketbase_go/my_models"
+	"pocketbase_go/services/mocks"
+	"testing"
+)
+
+func TestIdempotencyServiceReplaysCompletedRequests(t *testing.T) {
+	service := IdempotencyService{Repo: mocks.NewMockIdempotencyRepo()}
+
+	if stored, err := service.Begin("key", "body"); stored != nil || err != nil {
+		t.Fatalf("expected the first request to be processed, got %v %v", stored, err)
+	}
+	if _, err := service.Begin("key", "body"); !errors.Is(err, my_models.ErrIdempotencyKeyInProgress) {
+		t.Fatalf("expected a retry while processing to conflict, got %v", err)
+	}
+
+	if err := service.Complete("key", "body", 201, "application/json", []byte(`{"id":"1"}`)); err != nil {
+		t.Fatalf("expected the response to be stored, got %v", err)
+	}
+
+	stored, err := service.Begin("key", "body")
+	if err != nil || stored == nil {
+		t.Fatalf("expected the stored response to be replayed, got %v %v", stored, err)
+	}
+	if stored.StatusCode != 201 || stored.Body != `{"id":"1"}` {
+		t.Errorf("expected the original response, got %d %s", stored.StatusCode, stored.Body)
+	}
+}
+
+func TestIdempotencyServiceConflicts(t *testing.T) {
+	cases := map[string]struct {
+		complete bool
+		hash     string
+		err      error
+	}{
+		"different body while processing": {false, "other", my_models.ErrIdempotencyKeyReused},
+		"different body once completed":   {true, "other", my_models.ErrIdempotencyKeyReused},
+		"same body while processing":      {false, "body", my_models.ErrIdempotencyKeyInProgress},
+	}
+	for name, c := range cases {
+		service := IdempotencyService{Repo: mocks.NewMockIdempotencyRepo()}
+		service.Begin("key", "body")
+		if c.complete {
+			service.Complete("key", "body", 200, "application/json", nil)
+		}
+
+		if _, err := service.Begin("key", c.hash); !errors.Is(err, c.err) {
+			t.Errorf("%s: expected %v, got %v", name, c.err, err)
+		}
+	}
+}
+
+func TestIdempotencyServiceReleaseAllowsRetry(t *testing.T) {
+	service := IdempotencyService{Repo: mocks.NewMockIdempotencyRepo()}
+	service.Begin("key", "body")
+
+	if err := service.Release("key"); err != nil {
+		t.Fatalf("expected the key to be released, got %v", err)
+	}
+	if stored, err := service.Begin("key", "other"); stored != nil || err != nil {
+		t.Errorf("expected a released key to be usable again, got %v %v", stored, err)
+	}
+}
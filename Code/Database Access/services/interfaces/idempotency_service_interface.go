@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"pocketbase_go/my_models"
+)
+
+type IIdempotencyService interface {
+	Begin(key string, requestHash string) (*my_models.IdempotencyRecord, error)
+	Complete(key string, requestHash string, statusCode int, contentType string, body []byte) error
+	Release(key string) error
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"pocketbase_go/my_models"
+)
+
+type IMessagesService interface {
+	GetMessages(reservationId string) ([]my_models.Message, error)
+	SendMessage(message my_models.Message, attachments []my_models.MessageAttachment) (my_models.Message, error)
+	MarkMessagesRead(reservationId string, readerRole string) (int64, error)
+}
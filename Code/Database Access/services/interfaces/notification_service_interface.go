@@ -7,4 +7,5 @@ type INotificationService interface {
 	UnsubscribeFromChannel(subscriber string, channel string) error
 	MailMethod(email string) func(message string)
 	WhatsAppMethod(number string) func(message string)
+	SendMail(email string, message string) error
 }
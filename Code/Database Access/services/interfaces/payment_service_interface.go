@@ -5,6 +5,14 @@ import (
 )
 
 type IPaymentService interface {
-	PayProperty(propertyId string, cardInformation my_models.CardInformation) error
-	PayReservation(reservationId string, cardInformation my_models.CardInformation) error
+	PayProperty(propertyId string, cardToken string) error
+	PayReservation(reservationId string, cardToken string) error
+	StartReservationPayment(reservationId string, cardToken string) (my_models.Payment, error)
+	ProcessPaymentJob(body []byte) error
+	GetPayment(paymentId string) (my_models.Payment, error)
+	ValidatePropertyOwner(propertyId string, userId string) error
+	HandlePaymentEvent(event my_models.PaymentEvent) error
+	GetPaymentSchedule(reservationId string) ([]my_models.PaymentInstallment, error)
+	GetPaymentHistory(reservationId string) ([]my_models.Payment, error)
+	ChargeDueBalances() error
 }
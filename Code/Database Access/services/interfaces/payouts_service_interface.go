@@ -0,0 +1,10 @@
+package interfaces
+
+import (
+	"pocketbase_go/my_models"
+)
+
+type IPayoutsService interface {
+	GetOwnerPayouts(ownerId string) (my_models.OwnerPayouts, error)
+	ProcessPayouts() error
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"pocketbase_go/my_models"
+	"time"
+)
+
+type IPricingService interface {
+	Quote(propertyId string, fromDate time.Time, untilDate time.Time, country string) (my_models.PriceQuote, error)
+	QuoteReservation(reservation my_models.ReservationModel) (my_models.PriceQuote, error)
+	OwnerEarnings(quote my_models.PriceQuote, collected float64) my_models.OwnerEarnings
+	CancellationPolicy(property my_models.Property, country string) (my_models.CancellationPolicy, error)
+}
@@ -11,4 +11,11 @@ type IPropertyService interface {
 	AddProperty(property my_models.Property, userToken string) (string, error)
 	AddPropertyImage(id string, image multipart.File, fileExtension string, userToken string) error
 	GetFilteredProperties(filter my_models.PropertyFilter) ([]my_models.Property, error)
+	UpdateInstantBook(propertyId string, instantBook bool, rules my_models.InstantBookRules, userToken string) error
+	UpdateBookingRules(propertyId string, rules my_models.BookingRules, userToken string) error
+	UpdateCancellationPolicy(propertyId string, policy *my_models.CancellationPolicy, userToken string) error
+	UpdatePaymentTerms(propertyId string, terms my_models.PaymentTerms, userToken string) error
+	AddPriceRule(propertyId string, rule my_models.PriceRule, userToken string) (string, error)
+	GetPriceRules(propertyId string) ([]my_models.PriceRule, error)
+	RemovePriceRule(propertyId string, ruleId string, userToken string) error
 }
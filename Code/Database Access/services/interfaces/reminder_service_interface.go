@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"pocketbase_go/my_models"
+)
+
+type IReminderService interface {
+	ScheduleApprovalReminders(reservation my_models.ReservationModel) error
+	SchedulePaidReminders(reservation my_models.ReservationModel) error
+	ScheduleCheckOutReminders(reservation my_models.ReservationModel) error
+	CancelReminders(reservationId string) error
+	SendDueReminders() error
+}
@@ -11,6 +11,7 @@ type IReportsService interface {
 	ValidateSensorReport(report mongo_models.SensorReport) (mongo_models.SensorReport, error)
 	ValidateSecurityReport(report mongo_models.SensorReport) error
 	GetPropertiesIncomes(property_id string, fromDate time.Time, untilDate time.Time) (my_models.IncomeReport, error)
+	GetFrequentHostCancellers(fromDate time.Time, untilDate time.Time, minCancellations int) ([]my_models.HostCancellationsReportItem, error)
 	GetOccupations(fromDate time.Time, untilDate time.Time) ([]my_models.OccupationsReportItem, error)
 	GetPropertiesRanking(fromDate time.Time, untilDate time.Time) ([]mongo_models.RankingReportItem, error)
 }
@@ -5,15 +5,26 @@ import (
 )
 
 type IReservationService interface {
-	CreateReservation(reservation my_models.ReservationModel) error
-	GetFilteredReservations(filter my_models.ReservationFilter) ([]my_models.ReservationModel, error)
+	CreateReservation(reservation my_models.ReservationModel) (my_models.ReservationModel, error)
+	GetFilteredReservations(filter my_models.ReservationFilter) (my_models.ReservationsPage, error)
+	ExportReservations(filter my_models.ReservationFilter, format string) ([]byte, error)
 	NotifyValidReservation(reservation my_models.ReservationModel, ownerEmail string) error
 	GetOwnReservation(email string, propertyId string) (my_models.ReservationModel, error)
+	GetTenantReservations(email string, filter my_models.TenantReservationFilter) (my_models.TenantReservationsPage, error)
 	ApproveReservation(reservationId string) error
+	RejectReservation(reservationId string, reason string) error
+	ValidatePropertyOwner(reservationId string, userId string) error
 	RemoveReservation(reservationId string) error
 	CancelReservation(email string, reservationId string) (refundPercentage float64, err error)
+	HostCancelReservation(reservationId string, reason string, cancelledBy string, blockDates bool) (refund float64, err error)
+	RefundReservation(reservationId string, amount float64) ([]my_models.Payment, error)
+	GetGuests(reservationId string) ([]my_models.Guest, error)
+	UpdateGuests(email string, reservationId string, guests []my_models.Guest) ([]my_models.Guest, error)
 	DoCheckIn(reservationId string) error
 	DoCheckOut(reservationId string) error
 	GetReservationById(reservationId string) (my_models.ReservationModel, error)
 	AutoCancelReservations() error
+	ExpirePendingReservations() error
+	ProcessNoShows() error
+	AutoCheckOutReservations() error
 }
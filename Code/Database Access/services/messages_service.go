@@ -0,0 +1,107 @@
+package services
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/repos/interfaces"
+	serviceInterfaces "pocketbase_go/services/interfaces"
+	"strings"
+)
+
+// MessagesService keeps the conversation between the tenant and the owner of a
+// reservation. Attachments are stored with the property images.
+type MessagesService struct {
+	Repo                interfaces.IMessagesRepo
+	ReservationRepo     interfaces.IReservationRepo
+	PropertiesRepo      interfaces.IPropertyRepo
+	UserRepo            interfaces.IUserRepo
+	NotificationService serviceInterfaces.INotificationService
+}
+
+func (s *MessagesService) GetMessages(reservationId string) ([]my_models.Message, error) {
+	logger.Info("Service: Getting messages of reservation ", reservationId)
+	if _, err := s.ReservationRepo.GetReservationById(reservationId); err != nil {
+		return nil, err
+	}
+
+	messages, err := s.Repo.GetMessages(reservationId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range messages {
+		s.resolveAttachments(&messages[i])
+	}
+	return messages, nil
+}
+
+// SendMessage stores the message and its attachments and lets the other party
+// of the reservation know about it.
+func (s *MessagesService) SendMessage(message my_models.Message, attachments []my_models.MessageAttachment) (my_models.Message, error) {
+	logger.Info("Service: Sending message to reservation ", message.ReservationId)
+	if err := message.Validate(len(attachments)); err != nil {
+		return my_models.Message{}, err
+	}
+
+	reservation, err := s.ReservationRepo.GetReservationById(message.ReservationId)
+	if err != nil {
+		return my_models.Message{}, err
+	}
+
+	message.Attachments = make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		fileName, err := s.PropertiesRepo.StoreImage(attachment.File, attachment.Extension)
+		if err != nil {
+			logger.Error("Service: Error storing message attachment: ", err)
+			return my_models.Message{}, fmt.Errorf("failed to store attachment")
+		}
+		message.Attachments = append(message.Attachments, fileName)
+	}
+
+	message, err = s.Repo.AddMessage(message)
+	if err != nil {
+		return my_models.Message{}, err
+	}
+	s.resolveAttachments(&message)
+
+	s.notifyRecipient(reservation, message)
+	return message, nil
+}
+
+// MarkMessagesRead marks as read what the other party sent to the reader.
+func (s *MessagesService) MarkMessagesRead(reservationId string, readerRole string) (int64, error) {
+	logger.Info("Service: Marking messages of reservation ", reservationId, " as read")
+	if _, err := s.ReservationRepo.GetReservationById(reservationId); err != nil {
+		return 0, err
+	}
+
+	return s.Repo.MarkMessagesRead(reservationId, readerRole)
+}
+
+func (s *MessagesService) notifyRecipient(reservation my_models.ReservationModel, message my_models.Message) {
+	recipient := reservation.Email
+	if message.SenderRole == my_models.TenantSender {
+		ownerEmail, err := s.UserRepo.GetPropertyOwner(reservation.PropertyId)
+		if err != nil {
+			logger.Error("Service: Error getting owner of property ", reservation.PropertyId, ": ", err)
+			return
+		}
+		recipient = ownerEmail
+	}
+
+	notification := fmt.Sprintf("New message from the %s of reservation %s: %s", strings.ToLower(message.SenderRole), reservation.ID, message.Body)
+	if len(message.Attachments) > 0 {
+		notification += fmt.Sprintf(" (%d attachments)", len(message.Attachments))
+	}
+
+	if err := s.NotificationService.SendMail(recipient, notification); err != nil {
+		logger.Error("Service: Error notifying new message: ", err)
+	}
+}
+
+func (s *MessagesService) resolveAttachments(message *my_models.Message) {
+	for i, fileName := range message.Attachments {
+		message.Attachments[i] = s.PropertiesRepo.GetImageUrl(fileName)
+	}
+}
@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"pocketbase_go/my_models"
+	"sync"
+)
+
+// MockIdempotencyRepo keeps the idempotency records in memory.
+type MockIdempotencyRepo struct {
+	mutex   sync.Mutex
+	records map[string]my_models.IdempotencyRecord
+}
+
+func NewMockIdempotencyRepo() *MockIdempotencyRepo {
+	return &MockIdempotencyRepo{records: map[string]my_models.IdempotencyRecord{}}
+}
+
+func (m *MockIdempotencyRepo) Reserve(record my_models.IdempotencyRecord) (*my_models.IdempotencyRecord, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if stored, ok := m.records[record.Key]; ok {
+		return &stored, nil
+	}
+	m.records[record.Key] = record
+	return nil, nil
+}
+
+func (m *MockIdempotencyRepo) Complete(record my_models.IdempotencyRecord) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.records[record.Key] = record
+	return nil
+}
+
+func (m *MockIdempotencyRepo) Release(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.records, key)
+	return nil
+}
@@ -0,0 +1,77 @@
+package mocks
+
+import (
+	"fmt"
+	"pocketbase_go/my_models"
+	"sync"
+)
+
+// MockPaymentsRepo keeps the payments ledger in memory, in insertion order.
+type MockPaymentsRepo struct {
+	mutex    sync.Mutex
+	payments []my_models.Payment
+}
+
+func NewMockPaymentsRepo(payments ...my_models.Payment) *MockPaymentsRepo {
+	return &MockPaymentsRepo{payments: payments}
+}
+
+func (m *MockPaymentsRepo) AddPayment(payment my_models.Payment) (my_models.Payment, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	payment.Id = fmt.Sprintf("payment_%d", len(m.payments)+1)
+	m.payments = append(m.payments, payment)
+	return payment, nil
+}
+
+func (m *MockPaymentsRepo) GetPaymentById(id string) (my_models.Payment, error) {
+	return m.find(func(payment my_models.Payment) bool { return payment.Id == id })
+}
+
+func (m *MockPaymentsRepo) GetPaymentByReference(gatewayReference string) (my_models.Payment, error) {
+	return m.find(func(payment my_models.Payment) bool { return payment.GatewayReference == gatewayReference })
+}
+
+func (m *MockPaymentsRepo) UpdatePaymentResult(id string, status string, gatewayReference string, failureReason string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.payments {
+		if m.payments[i].Id == id {
+			m.payments[i].Status = status
+			m.payments[i].GatewayReference = gatewayReference
+			m.payments[i].FailureReason = failureReason
+			return nil
+		}
+	}
+	return fmt.Errorf("payment %s not found", id)
+}
+
+func (m *MockPaymentsRepo) GetReservationPayments(reservationId string) ([]my_models.Payment, error) {
+	return m.filter(func(payment my_models.Payment) bool { return payment.ReservationId == reservationId }), nil
+}
+
+func (m *MockPaymentsRepo) GetPropertyPayments(propertyId string) ([]my_models.Payment, error) {
+	return m.filter(func(payment my_models.Payment) bool { return payment.PropertyId == propertyId }), nil
+}
+
+func (m *MockPaymentsRepo) find(match func(my_models.Payment) bool) (my_models.Payment, error) {
+	if payments := m.filter(match); len(payments) > 0 {
+		return payments[0], nil
+	}
+	return my_models.Payment{}, fmt.Errorf("payment not found")
+}
+
+func (m *MockPaymentsRepo) filter(match func(my_models.Payment) bool) []my_models.Payment {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var payments []my_models.Payment
+	for _, payment := range m.payments {
+		if match(payment) {
+			payments = append(payments, payment)
+		}
+	}
+	return payments
+}
@@ -97,6 +97,16 @@ func (n *NotificationService) WhatsAppMethod(number string) func(message string)
 	return handler
 }
 
+func (n *NotificationService) SendMail(email string, message string) error {
+	if email == "" {
+		logger.Error("Service: Cannot send mail without a recipient")
+		return fmt.Errorf("mail recipient must be provided")
+	}
+
+	logger.Info("Notifications Service - Mail sent to ", email, ":", message)
+	return nil
+}
+
 func subscriberChannelKey(subscriber string, channel string) string {
 	return subscriber + ":" + channel
 }
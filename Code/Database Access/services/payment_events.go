@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+)
+
+// HandlePaymentEvent applies the outcome of a charge or refund the
+// Payment-Module settled in the background. Each event is applied once, and
+// payments that are no longer Pending are left as they are. An error means the
+// event could not be applied yet and should be delivered again.
+func (p *PaymentService) HandlePaymentEvent(event my_models.PaymentEvent) error {
+	logger.Info("Service: Handling payment event ", event.Id, " for ", event.Reference)
+	if err := event.Validate(); err != nil {
+		return err
+	}
+
+	isNew, err := p.PaymentEventsRepo.AddEvent(event)
+	if err != nil {
+		return err
+	}
+	if !isNew {
+		logger.Warn("Service: Payment event ", event.Id, " was already handled")
+		return nil
+	}
+
+	if err := p.applyPaymentEvent(event); err != nil {
+		logger.Error("Service: Error applying payment event ", event.Id, ": ", err)
+		if err := p.PaymentEventsRepo.RemoveEvent(event.Id); err != nil {
+			logger.Error("Service: Payment event ", event.Id, " could not be released: ", err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *PaymentService) applyPaymentEvent(event my_models.PaymentEvent) error {
+	// The event may arrive before the reference of the call was stored, the
+	// error makes the Payment-Module deliver it again later
+	payment, err := p.PaymentsRepo.GetPaymentByReference(event.Reference)
+	if err != nil {
+		return err
+	}
+
+	if payment.Status != my_models.PaymentPending {
+		logger.Warn("Service: Payment ", payment.Id, " is already ", payment.Status, ", event ", event.Id, " ignored")
+		return nil
+	}
+
+	var eventErr error
+	if event.Status == my_models.EventFailed {
+		eventErr = errors.New(event.Reason)
+		if event.Reason == "" {
+			eventErr = fmt.Errorf("%s failed", event.Type)
+		}
+	}
+
+	switch {
+	case payment.Kind == my_models.RefundPayment:
+		return p.settlePayment(payment, eventErr)
+	case payment.PropertyId != "":
+		return p.settlePropertyCharge(payment, eventErr)
+	default:
+		return p.settleReservationCharge(payment, eventErr)
+	}
+}
+
+func (p *PaymentService) settlePayment(payment my_models.Payment, eventErr error) error {
+	status := my_models.PaymentSucceeded
+	failureReason := ""
+	if eventErr != nil {
+		status = my_models.PaymentFailed
+		failureReason = eventErr.Error()
+		logger.Error("Service: ", payment.Kind, " ", payment.Id, " failed: ", eventErr)
+	}
+
+	return p.PaymentsRepo.UpdatePaymentResult(payment.Id, status, payment.GatewayReference, failureReason)
+}
+
+func (p *PaymentService) settlePropertyCharge(payment my_models.Payment, eventErr error) error {
+	if err := p.settlePayment(payment, eventErr); err != nil {
+		return err
+	}
+	if eventErr != nil {
+		return nil
+	}
+
+	if err := p.PropertyRepo.UpdatePropertyPaidStatus(payment.PropertyId); err != nil {
+		return err
+	}
+	logger.Info("Service: Property ", payment.PropertyId, " paid successfully")
+	return nil
+}
+
+// settleReservationCharge completes the payment the charge belongs to: the
+// first one of an Approved reservation or the balance of a PartiallyPaid one.
+func (p *PaymentService) settleReservationCharge(payment my_models.Payment, eventErr error) error {
+	reservation, err := p.ReservationRepo.GetReservationById(payment.ReservationId)
+	if err != nil {
+		return err
+	}
+
+	switch reservation.Status {
+	case "Approved":
+		plan, err := p.planReservationPayment(reservation.ID, payment.CardToken)
+		if err != nil {
+			logger.Error("Service: Charge ", payment.Id, " settled but reservation ", reservation.ID, " cannot be completed: ", err)
+			return p.settlePayment(payment, eventErr)
+		}
+		p.finishReservationCharge(reservation.ID, payment, payment.GatewayReference, eventErr)
+		if eventErr != nil {
+			return nil
+		}
+		if plan.amount != payment.Amount {
+			logger.Warn("Service: Reservation ", reservation.ID, " was charged ", payment.Amount, " but now costs ", plan.amount)
+		}
+		return p.completeReservationPayment(plan)
+
+	case "PartiallyPaid":
+		installment, err := p.pendingBalance(reservation.ID)
+		if err != nil {
+			return err
+		}
+		if err := p.settlePayment(payment, eventErr); err != nil {
+			return err
+		}
+		if eventErr != nil {
+			return p.balanceChargeFailed(reservation, installment, eventErr)
+		}
+		return p.balancePaid(reservation, installment)
+
+	default:
+		// Nothing to complete anymore, an Admin can refund the charge from the ledger
+		logger.Warn("Service: Charge ", payment.Id, " settled for reservation ", reservation.ID, " in status ", reservation.Status)
+		return p.settlePayment(payment, eventErr)
+	}
+}
+
+func (p *PaymentService) pendingBalance(reservationId string) (my_models.PaymentInstallment, error) {
+	installments, err := p.PaymentSchedulesRepo.GetInstallments(reservationId)
+	if err != nil {
+		return my_models.PaymentInstallment{}, err
+	}
+
+	for _, installment := range installments {
+		if installment.Kind == my_models.BalanceInstallment && installment.Status == my_models.InstallmentPending {
+			return installment, nil
+		}
+	}
+	return my_models.PaymentInstallment{}, fmt.Errorf("reservation %s has no pending balance", reservationId)
+}
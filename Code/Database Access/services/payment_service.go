@@ -1,30 +1,47 @@
 package services
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 	"time"
 
+	"pocketbase_go/gateways"
 	"pocketbase_go/logger"
 	"pocketbase_go/my_models"
 	interfaces "pocketbase_go/repos/interfaces"
+	serviceInterfaces "pocketbase_go/services/interfaces"
+	"pocketbase_go/workers"
 )
 
+const paymentsQueue = "payments"
+
 type PaymentService struct {
-	PropertyRepo    interfaces.IPropertyRepo
-	ReservationRepo interfaces.IReservationRepo
-	UsersRepo       interfaces.IUserRepo
-	paymentUrl string
+	PropertyRepo         interfaces.IPropertyRepo
+	ReservationRepo      interfaces.IReservationRepo
+	UsersRepo            interfaces.IUserRepo
+	PaymentSchedulesRepo interfaces.IPaymentSchedulesRepo
+	PaymentsRepo         interfaces.IPaymentsRepo
+	PricingService       serviceInterfaces.IPricingService
+	ReminderService      serviceInterfaces.IReminderService
+	NotificationService  serviceInterfaces.INotificationService
+	Gateway              gateways.PaymentGateway
+	Worker               workers.Worker
+	PaymentEventsRepo    interfaces.IPaymentEventsRepo
+	balanceRetryHours    int
+	balanceMaxAttempts   int
 }
 
-func (p *PaymentService) SetConfigValues(paymentUrl string) {
-	p.paymentUrl = paymentUrl
+func (p *PaymentService) SetConfigValues(balanceRetryHours int, balanceMaxAttempts int) {
+	p.balanceRetryHours = balanceRetryHours
+	p.balanceMaxAttempts = balanceMaxAttempts
 }
 
-func (p *PaymentService) PayProperty(propertyId string, cardInformation my_models.CardInformation) error {
+func (p *PaymentService) PayProperty(propertyId string, cardToken string) error {
 	logger.Info("Service: Paying property with id: ", propertyId)
+	if err := requireCardToken(cardToken); err != nil {
+		return err
+	}
 	price := 1000
 
 	property, err := p.PropertyRepo.GetPropertyById(propertyId)
@@ -41,127 +58,448 @@ func (p *PaymentService) PayProperty(propertyId string, cardInformation my_model
 		return fmt.Errorf("Property has already been paid")
 	}
 
-	requestBody := map[string]interface{}{
-		"cardInformation": cardInformation,
-		"price":           price,
+	payments, err := p.PaymentsRepo.GetPropertyPayments(propertyId)
+	if err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		if payment.Kind == my_models.ChargePayment && payment.Status == my_models.PaymentPending {
+			return fmt.Errorf("a payment of property %s is already being processed", propertyId)
+		}
 	}
 
-	bodyJSON, err := json.Marshal(requestBody)
-	if err != nil {
+	if err := p.charge(my_models.Payment{PropertyId: propertyId, Amount: float64(price)}, cardToken); err != nil {
 		logger.Error("Service: Error in PayProperty: ", err)
 		return err
 	}
 
-	req, err := http.NewRequest("POST", p.paymentUrl, bytes.NewBuffer(bodyJSON))
+	err = p.PropertyRepo.UpdatePropertyPaidStatus(propertyId)
 	if err != nil {
 		logger.Error("Service: Error in PayProperty: ", err)
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	logger.Info("Service: Property paid successfully")
+	return nil
+}
+
+// reservationPayment is what paying a reservation charges now and what is
+// left scheduled for later.
+type reservationPayment struct {
+	reservation  my_models.ReservationModel
+	property     my_models.Property
+	amount       float64
+	newStatus    string
+	installments []my_models.PaymentInstallment
+}
+
+// PayReservation charges the whole stay, or only the deposit when the property
+// payment terms split it. In that case the balance is scheduled to be charged
+// with the same card before arrival.
+func (p *PaymentService) PayReservation(reservationId string, cardToken string) error {
+	logger.Info("Service: Paying reservation with id: ", reservationId)
+	if err := requireCardToken(cardToken); err != nil {
+		return err
+	}
+	if err := p.checkNoPendingCharge(reservationId); err != nil {
+		return err
+	}
 
-	resp, err := client.Do(req)
+	payment, err := p.planReservationPayment(reservationId, cardToken)
 	if err != nil {
-		logger.Error("Service: Error in PayProperty: ", err)
+		logger.Error("Service: Error in PayReservation: ", err)
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		logger.Error("Service: Error in PayProperty: %d", resp.StatusCode)
-		return fmt.Errorf("Something went wrong: %d", resp.StatusCode)
+	if err := p.charge(my_models.Payment{ReservationId: reservationId, Amount: payment.amount}, cardToken); err != nil {
+		logger.Error("Service: Error in PayReservation: ", err)
+		return err
 	}
 
-	err = p.PropertyRepo.UpdatePropertyPaidStatus(propertyId)
+	return p.completeReservationPayment(payment)
+}
+
+func (p *PaymentService) checkNoPendingCharge(reservationId string) error {
+	pending, err := hasPendingCharge(p.PaymentsRepo, reservationId)
 	if err != nil {
-		logger.Error("Service: Error in PayProperty: ", err)
 		return err
 	}
-
-	logger.Info("Service: Property paid successfully")
+	if pending {
+		return fmt.Errorf("a payment of reservation %s is already being processed", reservationId)
+	}
 	return nil
 }
 
-func (p *PaymentService) PayReservation(reservationId string, cardInformation my_models.CardInformation) error {
-	logger.Info("Service: Paying reservation with id: ", reservationId)
+func (p *PaymentService) planReservationPayment(reservationId string, cardToken string) (reservationPayment, error) {
 	reservation, err := p.ReservationRepo.GetReservationById(reservationId)
 	if err != nil {
-		return err
+		return reservationPayment{}, err
 	}
 
 	property, err := p.PropertyRepo.GetPropertyById(reservation.PropertyId)
 	if err != nil {
-		return err
+		return reservationPayment{}, err
 	}
 
 	status := reservation.Status
 	if status != "Approved" {
-		return fmt.Errorf("Reservation is not approved")
+		return reservationPayment{}, fmt.Errorf("Reservation is not approved")
 	}
 
-	price := property.BookingPrice
-
-	reservationStartDate, err := time.Parse(my_models.PocketTimeLayout, reservation.ReservedFrom)
+	quote, err := p.PricingService.QuoteReservation(reservation)
 	if err != nil {
-		return err
+		return reservationPayment{}, err
 	}
-	reservationEndDate, err := time.Parse(my_models.PocketTimeLayout, reservation.ReservedUntil)
+
+	checkInDate, err := my_models.ParseReservationDate(reservation.ReservedFrom)
 	if err != nil {
-		return err
+		return reservationPayment{}, err
 	}
 
-	timeDifference := reservationEndDate.Sub(reservationStartDate)
-	days := int(timeDifference.Hours() / 24)
-
-	totalPrice := price * days
+	now := time.Now().UTC()
+	today := now.Format(time.DateOnly)
+	paidAt := now.Format(my_models.PocketTimeLayout)
+	payment := reservationPayment{
+		reservation: reservation,
+		property:    property,
+		amount:      quote.Total,
+		newStatus:   "Paid",
+		installments: []my_models.PaymentInstallment{
+			{Kind: my_models.FullInstallment, Amount: quote.Total, DueDate: today, Status: my_models.InstallmentPaid, PaidAt: paidAt},
+		},
+	}
 
-	requestBody := map[string]interface{}{
-		"cardInformation": cardInformation,
-		"price":           totalPrice,
+	terms := property.PaymentTerms
+	if terms.SplitsPayment(checkInDate, now) {
+		deposit := my_models.RoundPrice(quote.Total * terms.DepositPercentage / 100)
+		payment.amount = deposit
+		payment.newStatus = "PartiallyPaid"
+		payment.installments = []my_models.PaymentInstallment{
+			{Kind: my_models.DepositInstallment, Amount: deposit, DueDate: today, Status: my_models.InstallmentPaid, PaidAt: paidAt},
+			{
+				Kind:      my_models.BalanceInstallment,
+				Amount:    my_models.RoundPrice(quote.Total - deposit),
+				DueDate:   terms.BalanceDueDate(checkInDate).Format(time.DateOnly),
+				Status:    my_models.InstallmentPending,
+				CardToken: cardToken,
+			},
+		}
 	}
 
-	bodyJSON, err := json.Marshal(requestBody)
+	return payment, nil
+}
+
+// completeReservationPayment updates the reservation once its charge went through.
+func (p *PaymentService) completeReservationPayment(payment reservationPayment) error {
+	reservationId := payment.reservation.ID
+	err := p.ReservationRepo.UpdateReservationStatus(reservationId, payment.newStatus)
 	if err != nil {
 		logger.Error("Service: Error in PayReservation: ", err)
 		return err
 	}
+	if err := p.PaymentSchedulesRepo.AddInstallments(reservationId, payment.installments); err != nil {
+		logger.Error("Service: Error in PayReservation: ", err)
+		return err
+	}
+	if err := p.ReminderService.SchedulePaidReminders(payment.reservation); err != nil {
+		logger.Error("Service: Error scheduling reminders of reservation ", reservationId, ": ", err)
+	}
 
-	req, err := http.NewRequest("POST", p.paymentUrl, bytes.NewBuffer(bodyJSON))
+	admins, err := p.UsersRepo.GetUsersByRole("Admin")
 	if err != nil {
 		logger.Error("Service: Error in PayReservation: ", err)
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	for _, admin := range admins {
+		logger.Info("Service: Notifying admin: ", admin, " about reservation: ", reservationId)
+	}
+	logger.Info("Service: Notifying owner of property: ", payment.property.Owner, " about reservation: ", reservationId)
+	logger.Info("Service: Reservation ", reservationId, " charged ", payment.amount, ", status ", payment.newStatus)
+	return nil
+}
+
+// StartReservationPayment records the charge of the reservation as pending and
+// queues it, so the request does not wait for the payment provider. The result
+// can be polled with GetPayment and is mailed to the tenant.
+func (p *PaymentService) StartReservationPayment(reservationId string, cardToken string) (my_models.Payment, error) {
+	logger.Info("Service: Queueing payment of reservation with id: ", reservationId)
+	if p.Worker == nil || p.Worker.Health() != nil {
+		logger.Error("Service: Payments queue is not available")
+		return my_models.Payment{}, fmt.Errorf("%w: the payments queue is not available", gateways.ErrPaymentsUnavailable)
+	}
+
+	if err := requireCardToken(cardToken); err != nil {
+		return my_models.Payment{}, err
+	}
+	if err := p.checkNoPendingCharge(reservationId); err != nil {
+		return my_models.Payment{}, err
+	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	plan, err := p.planReservationPayment(reservationId, cardToken)
+	if err != nil {
+		logger.Error("Service: Error in StartReservationPayment: ", err)
+		return my_models.Payment{}, err
+	}
 
-	resp, err := client.Do(req)
+	payment, err := p.PaymentsRepo.AddPayment(my_models.Payment{
+		ReservationId: reservationId,
+		Kind:          my_models.ChargePayment,
+		Amount:        plan.amount,
+		Status:        my_models.PaymentPending,
+		CardToken:     cardToken,
+	})
+	if err != nil {
+		return my_models.Payment{}, err
+	}
+
+	job, err := json.Marshal(my_models.PaymentJob{PaymentId: payment.Id, ReservationId: reservationId, CardToken: cardToken})
+	if err == nil {
+		err = p.Worker.Send(paymentsQueue, job)
+	}
+	if err != nil {
+		logger.Error("Service: Error queueing payment ", payment.Id, ": ", err)
+		if err := p.PaymentsRepo.UpdatePaymentResult(payment.Id, my_models.PaymentFailed, "", "could not be queued"); err != nil {
+			logger.Error("Service: Error updating payment ", payment.Id, ": ", err)
+		}
+		return my_models.Payment{}, fmt.Errorf("%w: the payment could not be queued", gateways.ErrPaymentsUnavailable)
+	}
+
+	logger.Info("Service: Payment ", payment.Id, " of reservation ", reservationId, " queued")
+	return payment, nil
+}
+
+// ProcessPaymentJob charges a queued reservation payment. Jobs delivered more
+// than once are ignored once their payment left the Pending status, and the
+// reservation is checked again since it may have changed while queued.
+func (p *PaymentService) ProcessPaymentJob(body []byte) error {
+	var job my_models.PaymentJob
+	if err := json.Unmarshal(body, &job); err != nil {
+		// A malformed job will never succeed, requeueing it would only loop
+		logger.Error("Service: Discarding malformed payment job: ", err)
+		return nil
+	}
+
+	payment, err := p.PaymentsRepo.GetPaymentById(job.PaymentId)
 	if err != nil {
-		logger.Error("Service: Error in PayReservation: ", err)
 		return err
 	}
-	defer resp.Body.Close()
+	if payment.Status != my_models.PaymentPending {
+		logger.Warn("Service: Payment ", payment.Id, " is already ", payment.Status, ", job ignored")
+		return nil
+	}
+
+	plan, err := p.planReservationPayment(job.ReservationId, job.CardToken)
+	if err == nil && plan.amount != payment.Amount {
+		err = fmt.Errorf("the amount to pay changed to %.2f, the reservation must be paid again", plan.amount)
+	}
+	if err != nil {
+		p.finishReservationCharge(job.ReservationId, payment, "", err)
+		return nil
+	}
+
+	reference, chargeErr := p.Gateway.Charge(job.CardToken, payment.Amount)
+	chargeErr = gatewayOutcome(reference, chargeErr)
+	if errors.Is(chargeErr, gateways.ErrPaymentPending) {
+		// The webhook of the Payment-Module completes it, or it is reconciled by hand when it has no reference
+		failureReason := ""
+		if errors.Is(chargeErr, gateways.ErrPaymentOutcomeUnknown) {
+			failureReason = chargeErr.Error()
+			logger.Error("Service: Payment ", payment.Id, " must be reconciled with the provider: ", chargeErr)
+		}
+		if err := p.PaymentsRepo.UpdatePaymentResult(payment.Id, my_models.PaymentPending, reference, failureReason); err != nil {
+			logger.Error("Service: Payment ", payment.Id, " is being settled but its reference could not be stored: ", err)
+		}
+		return nil
+	}
+	p.finishReservationCharge(job.ReservationId, payment, reference, chargeErr)
+	if chargeErr != nil {
+		return nil
+	}
+
+	return p.completeReservationPayment(plan)
+}
+
+// finishReservationCharge stores the outcome of a reservation charge that was
+// settled outside of the tenant request and tells the tenant about it.
+func (p *PaymentService) finishReservationCharge(reservationId string, payment my_models.Payment, reference string, chargeErr error) {
+	status := my_models.PaymentSucceeded
+	failureReason := ""
+	message := fmt.Sprintf("Your payment %s of %.2f for reservation %s went through", payment.Id, payment.Amount, reservationId)
+	if chargeErr != nil {
+		status = my_models.PaymentFailed
+		failureReason = chargeErr.Error()
+		message = fmt.Sprintf("Your payment %s for reservation %s failed: %s", payment.Id, reservationId, failureReason)
+		logger.Error("Service: Payment ", payment.Id, " failed: ", chargeErr)
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Something went wrong: %d", resp.StatusCode)
+	if err := p.PaymentsRepo.UpdatePaymentResult(payment.Id, status, reference, failureReason); err != nil {
+		logger.Error("Service: Payment ", payment.Id, " ended ", status, " but the ledger could not be updated: ", err)
 	}
 
-	err = p.ReservationRepo.UpdateReservationStatus(reservationId, "Paid")
+	reservation, err := p.ReservationRepo.GetReservationById(reservationId)
+	if err != nil {
+		logger.Error("Service: Error getting reservation ", reservationId, ": ", err)
+		return
+	}
+	if err := p.NotificationService.SendMail(reservation.Email, message); err != nil {
+		logger.Error("Service: Error notifying tenant about payment ", payment.Id, ": ", err)
+	}
+}
+
+func (p *PaymentService) GetPayment(paymentId string) (my_models.Payment, error) {
+	return p.PaymentsRepo.GetPaymentById(paymentId)
+}
+
+// ValidatePropertyOwner checks that the user owns the property, who is the one
+// paying for it.
+func (p *PaymentService) ValidatePropertyOwner(propertyId string, userId string) error {
+	property, err := p.PropertyRepo.GetPropertyById(propertyId)
 	if err != nil {
-		logger.Error("Service: Error in PayReservation: ", err)
 		return err
 	}
 
-	admins, err := p.UsersRepo.GetUsersByRole("Admin")
+	if property.Owner != userId {
+		logger.Error("Service: User ", userId, " is not the owner of property ", propertyId)
+		return fmt.Errorf("user is not the owner of the paid property")
+	}
+
+	return nil
+}
+
+func (p *PaymentService) GetPaymentSchedule(reservationId string) ([]my_models.PaymentInstallment, error) {
+	return p.PaymentSchedulesRepo.GetInstallments(reservationId)
+}
+
+func (p *PaymentService) GetPaymentHistory(reservationId string) ([]my_models.Payment, error) {
+	if _, err := p.ReservationRepo.GetReservationById(reservationId); err != nil {
+		return nil, err
+	}
+
+	return p.PaymentsRepo.GetReservationPayments(reservationId)
+}
+
+// ChargeDueBalances charges the balances that are due. A failed charge is
+// retried every balanceRetryHours, and after balanceMaxAttempts the
+// reservation is cancelled.
+func (p *PaymentService) ChargeDueBalances() error {
+	installments, err := p.PaymentSchedulesRepo.GetDueBalances(time.Now(), p.balanceMaxAttempts)
 	if err != nil {
-		logger.Error("Service: Error in PayReservation: ", err)
 		return err
 	}
-	for _, admin := range admins {
-		logger.Info("Service: Notifying admin: ", admin, " about reservation: ", reservationId)
+
+	for _, installment := range installments {
+		reservation, err := p.ReservationRepo.GetReservationById(installment.ReservationId)
+		if err != nil {
+			logger.Error("Service: Error getting reservation of balance ", installment.Id, ": ", err)
+			continue
+		}
+
+		if reservation.Status != "PartiallyPaid" {
+			logger.Warn("Service: Reservation ", reservation.ID, " is ", reservation.Status, ", its balance is not charged")
+			if err := p.PaymentSchedulesRepo.CancelPendingInstallments(reservation.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		pending, err := hasPendingCharge(p.PaymentsRepo, reservation.ID)
+		if err != nil {
+			return err
+		}
+		if pending {
+			logger.Warn("Service: Balance of reservation ", reservation.ID, " is waiting for a pending charge")
+			continue
+		}
+
+		err = p.charge(my_models.Payment{ReservationId: reservation.ID, Amount: installment.Amount}, installment.CardToken)
+		if errors.Is(err, gateways.ErrPaymentPending) {
+			logger.Info("Service: Balance of reservation ", reservation.ID, " is being settled")
+			continue
+		}
+		if err != nil {
+			logger.Error("Service: Error charging balance of reservation ", reservation.ID, ": ", err)
+			if err := p.balanceChargeFailed(reservation, installment, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := p.balancePaid(reservation, installment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *PaymentService) balancePaid(reservation my_models.ReservationModel, installment my_models.PaymentInstallment) error {
+	if err := p.PaymentSchedulesRepo.MarkInstallmentPaid(installment.Id); err != nil {
+		return err
+	}
+	if err := p.ReservationRepo.UpdateReservationStatus(reservation.ID, "Paid"); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("The balance of %.2f of your reservation %s was charged, your stay is fully paid", installment.Amount, reservation.ID)
+	if err := p.NotificationService.SendMail(reservation.Email, message); err != nil {
+		logger.Error("Service: Error notifying tenant about balance charge: ", err)
+	}
+	return nil
+}
+
+func (p *PaymentService) balanceChargeFailed(reservation my_models.ReservationModel, installment my_models.PaymentInstallment, chargeErr error) error {
+	final := installment.Attempts+1 >= p.balanceMaxAttempts
+	retryAt := time.Now().UTC().Add(time.Duration(p.balanceRetryHours) * time.Hour)
+	if err := p.PaymentSchedulesRepo.MarkInstallmentFailed(installment.Id, chargeErr.Error(), retryAt, final); err != nil {
+		return err
+	}
+
+	if !final {
+		message := fmt.Sprintf("We could not charge the balance of %.2f of your reservation %s, we will try again at %s UTC", installment.Amount, reservation.ID, retryAt.Format(time.DateTime))
+		if err := p.NotificationService.SendMail(reservation.Email, message); err != nil {
+			logger.Error("Service: Error notifying tenant about failed balance charge: ", err)
+		}
+		return nil
+	}
+
+	// The deposit is kept, as with any reservation cancelled for lack of payment
+	if err := p.ReservationRepo.CancelReservationWithReason(reservation.ID, "balance payment failed", "System"); err != nil {
+		return err
+	}
+	if err := p.ReminderService.CancelReminders(reservation.ID); err != nil {
+		logger.Error("Service: Error cancelling reminders of reservation ", reservation.ID, ": ", err)
+	}
+
+	message := fmt.Sprintf("Your reservation %s was cancelled because its balance of %.2f could not be charged", reservation.ID, installment.Amount)
+	if err := p.NotificationService.SendMail(reservation.Email, message); err != nil {
+		logger.Error("Service: Error notifying tenant about cancelled reservation: ", err)
 	}
-	logger.Info("Service: Notifying owner of property: ", property.Owner, " about reservation: ", reservationId)
-	logger.Info("Service: Reservation paid successfully")
+	if ownerEmail, err := p.UsersRepo.GetPropertyOwner(reservation.PropertyId); err == nil {
+		message := fmt.Sprintf("Reservation %s was cancelled because its balance could not be charged", reservation.ID)
+		if err := p.NotificationService.SendMail(ownerEmail, message); err != nil {
+			logger.Error("Service: Error notifying owner about cancelled reservation: ", err)
+		}
+	}
+
+	logger.Info("Service: Reservation ", reservation.ID, " cancelled after ", installment.Attempts+1, " failed balance charges")
 	return nil
-}
\ No newline at end of file
+}
+
+// charge sends the charge to the gateway and records it in the payments ledger.
+func (p *PaymentService) charge(payment my_models.Payment, cardToken string) error {
+	payment.Kind = my_models.ChargePayment
+	payment.CardToken = cardToken
+	_, err := recordGatewayCall(p.PaymentsRepo, payment, func() (string, error) {
+		return p.Gateway.Charge(cardToken, payment.Amount)
+	})
+	return err
+}
+
+// requireCardToken rejects payments made without the token the Payment-Module
+// issues for a card, card details are never handled here.
+func requireCardToken(cardToken string) error {
+	if cardToken == "" {
+		return fmt.Errorf("a card token is required, tokenize the card with the Payment-Module first")
+	}
+	return nil
+}
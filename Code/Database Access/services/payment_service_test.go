@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"pocketbase_go/gateways"
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/repos/interfaces"
+	"pocketbase_go/services/mocks"
+	"testing"
+	"time"
+)
+
+const declinedCard = "tok_declined"
+
+type stubUserRepo struct {
+	interfaces.IUserRepo
+}
+
+func (r stubUserRepo) GetUsersByRole(role string) ([]string, error) {
+	return nil, nil
+}
+
+type stubPayingPropertyRepo struct {
+	stubPropertyRepo
+	paid bool
+}
+
+func (r *stubPayingPropertyRepo) UpdatePropertyPaidStatus(id string) error {
+	r.paid = true
+	return nil
+}
+
+func approvedReservation() my_models.ReservationModel {
+	from := time.Now().AddDate(0, 0, 30)
+	return my_models.ReservationModel{
+		ID:            "r1",
+		Email:         "tenant@mail.com",
+		PropertyId:    "property",
+		Country:       "UY",
+		Status:        "Approved",
+		ReservedFrom:  from.Format(time.DateOnly),
+		ReservedUntil: from.AddDate(0, 0, 3).Format(time.DateOnly),
+	}
+}
+
+func newTestPaymentService(reservationRepo interfaces.IReservationRepo, paymentsRepo interfaces.IPaymentsRepo, gateway gateways.PaymentGateway) *PaymentService {
+	property := my_models.Property{BookingPrice: 100, CleaningFee: 30}
+	return &PaymentService{
+		PropertyRepo:         stubPropertyRepo{property: property},
+		ReservationRepo:      reservationRepo,
+		UsersRepo:            stubUserRepo{},
+		PaymentSchedulesRepo: stubPaymentSchedulesRepo{},
+		PaymentsRepo:         paymentsRepo,
+		PricingService:       newTestPricingService(property, nil),
+		ReminderService:      stubReminderService{},
+		Gateway:              gateway,
+	}
+}
+
+func TestPayReservation(t *testing.T) {
+	pending := my_models.Payment{Id: "c1", ReservationId: "r1", Kind: my_models.ChargePayment, Amount: 100, Status: my_models.PaymentPending}
+
+	cases := []struct {
+		name     string
+		card     string
+		payments []my_models.Payment
+		fails    bool
+		status   string
+		charges  int
+	}{
+		{"charged", testCard, nil, false, "Paid", 1},
+		{"declined card", declinedCard, nil, true, "Approved", 0},
+		{"charge being settled", testCard, []my_models.Payment{pending}, true, "Approved", 0},
+	}
+	for _, c := range cases {
+		gateway := gateways.NewFakePaymentGateway()
+		gateway.FailCard(declinedCard, nil)
+		reservations := &stubReservationRepo{reservation: approvedReservation()}
+		payments := mocks.NewMockPaymentsRepo(c.payments...)
+		service := newTestPaymentService(reservations, payments, gateway)
+
+		err := service.PayReservation("r1", c.card)
+		if (err != nil) != c.fails {
+			t.Errorf("%s: expected failure %v, got %v", c.name, c.fails, err)
+		}
+		if c.card == declinedCard && !errors.Is(err, gateways.ErrCardDeclined) {
+			t.Errorf("%s: expected the card to be declined, got %v", c.name, err)
+		}
+		if reservations.reservation.Status != c.status {
+			t.Errorf("%s: expected reservation %s, got %s", c.name, c.status, reservations.reservation.Status)
+		}
+		if charges := len(gateway.Operations()); charges != c.charges {
+			t.Errorf("%s: expected %d charges, got %d", c.name, c.charges, charges)
+		}
+	}
+}
+
+func TestPayReservationRecordsDeclinedCharge(t *testing.T) {
+	gateway := gateways.NewFakePaymentGateway()
+	gateway.FailCard(declinedCard, nil)
+	payments := mocks.NewMockPaymentsRepo()
+	service := newTestPaymentService(&stubReservationRepo{reservation: approvedReservation()}, payments, gateway)
+
+	if err := service.PayReservation("r1", declinedCard); err == nil {
+		t.Fatalf("expected the declined card to fail")
+	}
+	if err := service.PayReservation("r1", testCard); err != nil {
+		t.Fatalf("expected another card to pay the reservation, got %v", err)
+	}
+
+	ledger, _ := payments.GetReservationPayments("r1")
+	if len(ledger) != 2 || ledger[0].Status != my_models.PaymentFailed || ledger[1].Status != my_models.PaymentSucceeded {
+		t.Errorf("expected a failed and a succeeded charge, got %v", ledger)
+	}
+	if ledger[0].FailureReason == "" {
+		t.Errorf("expected the reason the card was declined")
+	}
+}
+
+func TestPayProperty(t *testing.T) {
+	cases := []struct {
+		name string
+		card string
+		paid bool
+	}{
+		{"charged", testCard, true},
+		{"declined card", declinedCard, false},
+	}
+	for _, c := range cases {
+		gateway := gateways.NewFakePaymentGateway()
+		gateway.FailCard(declinedCard, nil)
+		properties := &stubPayingPropertyRepo{stubPropertyRepo: stubPropertyRepo{property: my_models.Property{Id: "property", IsPendingPayment: true}}}
+		service := newTestPaymentService(&stubReservationRepo{}, mocks.NewMockPaymentsRepo(), gateway)
+		service.PropertyRepo = properties
+
+		err := service.PayProperty("property", c.card)
+		if (err == nil) != c.paid {
+			t.Errorf("%s: expected paid %v, got %v", c.name, c.paid, err)
+		}
+		if properties.paid != c.paid {
+			t.Errorf("%s: expected the property paid %v, got %v", c.name, c.paid, properties.paid)
+		}
+	}
+}
+
+func TestCancelReservationAfterDeclinedCard(t *testing.T) {
+	gateway := gateways.NewFakePaymentGateway()
+	gateway.FailCard(declinedCard, nil)
+	reservations := &stubReservationRepo{reservation: approvedReservation()}
+	payments := mocks.NewMockPaymentsRepo()
+	paymentService := newTestPaymentService(reservations, payments, gateway)
+
+	paymentService.PayReservation("r1", declinedCard)
+	if err := paymentService.PayReservation("r1", testCard); err != nil {
+		t.Fatalf("expected the reservation to be paid, got %v", err)
+	}
+	charged := gateway.Operations()[0].Amount
+
+	reservationService := newTestReservationService(reservations.reservation, gateway)
+	reservationService.ReservationRepo = reservations
+	reservationService.PaymentsRepo = payments
+	if _, err := reservationService.CancelReservation("tenant@mail.com", "r1"); err != nil {
+		t.Fatalf("expected the reservation to be cancelled, got %v", err)
+	}
+
+	if refunds := refundAmounts(gateway); len(refunds) != 1 || refunds[0] != charged {
+		t.Errorf("expected only the %.2f charged to be refunded, got %v", charged, refunds)
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"pocketbase_go/gateways"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/repos/interfaces"
+)
+
+// recordGatewayCall writes the attempt to the payments ledger as Pending, runs
+// the gateway operation and stores its outcome. Nothing is sent to the gateway
+// when the ledger cannot be written. Operations the provider settles later stay
+// Pending with their reference until the webhook reports them. Operations
+// without a reference, or that reached the provider without an answer, also
+// stay Pending, with the reason they must be reconciled.
+func recordGatewayCall(repo interfaces.IPaymentsRepo, payment my_models.Payment, operation func() (string, error)) (my_models.Payment, error) {
+	payment.Status = my_models.PaymentPending
+	payment, err := repo.AddPayment(payment)
+	if err != nil {
+		return payment, err
+	}
+
+	reference, operationErr := operation()
+	operationErr = gatewayOutcome(reference, operationErr)
+	payment.GatewayReference = reference
+	payment.Status = my_models.PaymentSucceeded
+	if errors.Is(operationErr, gateways.ErrPaymentOutcomeUnknown) {
+		payment.Status = my_models.PaymentPending
+		payment.FailureReason = operationErr.Error()
+		logger.Error("Service: Payment ", payment.Id, " must be reconciled with the provider: ", operationErr)
+	} else if errors.Is(operationErr, gateways.ErrPaymentPending) {
+		payment.Status = my_models.PaymentPending
+	} else if operationErr != nil {
+		payment.Status = my_models.PaymentFailed
+		payment.FailureReason = operationErr.Error()
+	}
+
+	if err := repo.UpdatePaymentResult(payment.Id, payment.Status, payment.GatewayReference, payment.FailureReason); err != nil {
+		logger.Error("Service: Payment ", payment.Id, " ended ", payment.Status, " but the ledger could not be updated: ", err)
+	}
+
+	return payment, operationErr
+}
+
+// gatewayOutcome tells apart the calls whose outcome is unknown: the provider
+// answered without a reference, or the request may have reached it before it
+// became unavailable. Either may have moved money, so they are reconciled
+// instead of failed.
+func gatewayOutcome(reference string, err error) error {
+	if err == nil && reference == "" {
+		return gateways.ErrPaymentOutcomeUnknown
+	}
+
+	var unavailable *gateways.UnavailableError
+	if errors.As(err, &unavailable) && unavailable.Sent {
+		return fmt.Errorf("%w: %w", gateways.ErrPaymentOutcomeUnknown, err)
+	}
+	return err
+}
+
+// hasPendingCharge tells whether a charge of the reservation is still waiting
+// to be settled, in which case charging it again could take the money twice.
+func hasPendingCharge(repo interfaces.IPaymentsRepo, reservationId string) (bool, error) {
+	payments, err := repo.GetReservationPayments(reservationId)
+	if err != nil {
+		return false, err
+	}
+
+	for _, payment := range payments {
+		if payment.Kind == my_models.ChargePayment && payment.Status == my_models.PaymentPending {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"pocketbase_go/gateways"
+	"pocketbase_go/my_models"
+	"pocketbase_go/services/mocks"
+	"testing"
+)
+
+func TestRecordGatewayCall(t *testing.T) {
+	declined := errors.New("declined")
+	cases := []struct {
+		name      string
+		reference string
+		err       error
+		status    string
+		flagged   bool
+	}{
+		{"succeeded", "ch_1", nil, my_models.PaymentSucceeded, false},
+		{"settled later", "ch_1", gateways.ErrPaymentPending, my_models.PaymentPending, false},
+		{"failed", "", declined, my_models.PaymentFailed, true},
+		{"unknown outcome", "", gateways.ErrPaymentOutcomeUnknown, my_models.PaymentPending, true},
+		{"succeeded without a reference", "", nil, my_models.PaymentPending, true},
+		{"provider down before sending", "", &gateways.UnavailableError{Err: errors.New("connection refused")}, my_models.PaymentFailed, true},
+		{"provider down after sending", "", &gateways.UnavailableError{Err: errors.New("timeout"), Sent: true}, my_models.PaymentPending, true},
+	}
+	for _, c := range cases {
+		repo := mocks.NewMockPaymentsRepo()
+		payment, _ := recordGatewayCall(repo, my_models.Payment{ReservationId: "r1", Kind: my_models.ChargePayment, Amount: 10}, func() (string, error) {
+			return c.reference, c.err
+		})
+
+		stored, err := repo.GetPaymentById(payment.Id)
+		if err != nil {
+			t.Fatalf("%s: expected the payment in the ledger, got %v", c.name, err)
+		}
+		if stored.Status != c.status || stored.GatewayReference != c.reference {
+			t.Errorf("%s: expected %s %q, got %s %q", c.name, c.status, c.reference, stored.Status, stored.GatewayReference)
+		}
+		if (stored.FailureReason != "") != c.flagged {
+			t.Errorf("%s: expected flagged %v, got reason %q", c.name, c.flagged, stored.FailureReason)
+		}
+	}
+}
+
+func TestRecordGatewayCallWithoutReferenceBlocksCharges(t *testing.T) {
+	repo := mocks.NewMockPaymentsRepo()
+	_, err := recordGatewayCall(repo, my_models.Payment{ReservationId: "r1", Kind: my_models.ChargePayment, Amount: 10}, func() (string, error) {
+		return "", gateways.ErrPaymentOutcomeUnknown
+	})
+	if !errors.Is(err, gateways.ErrPaymentPending) {
+		t.Errorf("expected the payment to be reported as pending, got %v", err)
+	}
+
+	if pending, _ := hasPendingCharge(repo, "r1"); !pending {
+		t.Errorf("expected a charge to reconcile to block charging the reservation again")
+	}
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/repos/interfaces"
+	serviceInterfaces "pocketbase_go/services/interfaces"
+)
+
+// PayoutsService pays owners their earnings for the stays of their properties.
+// A payout is scheduled once the tenant checks in a paid stay and released
+// payoutDelayHours later, batched with the other due payouts of the owner.
+type PayoutsService struct {
+	Repo                interfaces.IPayoutsRepo
+	ReservationRepo     interfaces.IReservationRepo
+	PaymentsRepo        interfaces.IPaymentsRepo
+	PropertiesRepo      interfaces.IPropertyRepo
+	UserRepo            interfaces.IUserRepo
+	PricingService      serviceInterfaces.IPricingService
+	NotificationService serviceInterfaces.INotificationService
+	payoutDelayHours    int
+}
+
+func (s *PayoutsService) SetConfigValues(payoutDelayHours int) {
+	s.payoutDelayHours = payoutDelayHours
+}
+
+func (s *PayoutsService) GetOwnerPayouts(ownerId string) (my_models.OwnerPayouts, error) {
+	payouts, err := s.Repo.GetOwnerPayouts(ownerId)
+	if err != nil {
+		return my_models.OwnerPayouts{}, err
+	}
+
+	batches, err := s.Repo.GetOwnerBatches(ownerId)
+	if err != nil {
+		return my_models.OwnerPayouts{}, err
+	}
+
+	return my_models.NewOwnerPayouts(payouts, batches), nil
+}
+
+// ProcessPayouts schedules the payouts of the stays checked in since the last
+// run and releases the ones that are due.
+func (s *PayoutsService) ProcessPayouts() error {
+	if err := s.schedulePayouts(); err != nil {
+		return err
+	}
+	return s.releaseDuePayouts(time.Now().UTC())
+}
+
+func (s *PayoutsService) schedulePayouts() error {
+	reservations, err := s.Repo.GetStaysAwaitingPayout()
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		if _, err := s.schedulePayout(reservation); err != nil {
+			// The stay is picked up again on the next run
+			logger.Error("Service: Error scheduling payout of reservation ", reservation.ID, ": ", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *PayoutsService) schedulePayout(reservation my_models.ReservationModel) (my_models.Payout, error) {
+	checkIn, err := my_models.ParseReservationDate(reservation.CheckIn)
+	if err != nil {
+		return my_models.Payout{}, fmt.Errorf("invalid check in date: %w", err)
+	}
+
+	property, err := s.PropertiesRepo.GetPropertyById(reservation.PropertyId)
+	if err != nil {
+		return my_models.Payout{}, err
+	}
+
+	collected, err := s.collectedAmount(reservation.ID)
+	if err != nil {
+		return my_models.Payout{}, err
+	}
+
+	// The quote only tells how the collected money is split, prices may have changed since it was paid
+	quote, err := s.PricingService.QuoteReservation(reservation)
+	if err != nil {
+		return my_models.Payout{}, err
+	}
+	earnings := s.PricingService.OwnerEarnings(quote, collected)
+
+	return s.Repo.AddPayout(my_models.Payout{
+		ReservationId: reservation.ID,
+		PropertyId:    reservation.PropertyId,
+		OwnerId:       property.Owner,
+		Gross:         earnings.Gross,
+		Commission:    earnings.Commission,
+		Fees:          earnings.Fees,
+		Net:           earnings.Net,
+		Status:        my_models.PayoutScheduled,
+		DueAt:         checkIn.Add(time.Duration(s.payoutDelayHours) * time.Hour).Format(my_models.PocketTimeLayout),
+	})
+}
+
+// collectedAmount is what the ledger holds for the reservation: its succeeded
+// charges minus the refunds sent back from them. Stays with a charge still
+// being settled wait for it.
+func (s *PayoutsService) collectedAmount(reservationId string) (float64, error) {
+	pending, err := hasPendingCharge(s.PaymentsRepo, reservationId)
+	if err != nil {
+		return 0, err
+	}
+	if pending {
+		return 0, fmt.Errorf("a charge of reservation %s is still being settled", reservationId)
+	}
+
+	payments, err := s.PaymentsRepo.GetReservationPayments(reservationId)
+	if err != nil {
+		return 0, err
+	}
+
+	collected := my_models.TotalRefundable(my_models.RefundableCharges(payments))
+	if collected <= 0 {
+		return 0, fmt.Errorf("no money was collected for reservation %s", reservationId)
+	}
+	return collected, nil
+}
+
+// releaseDuePayouts pays every owner the sum of their due payouts in a single
+// batch. Payouts of stays cancelled after the check in are not paid.
+func (s *PayoutsService) releaseDuePayouts(now time.Time) error {
+	payouts, err := s.Repo.GetDuePayouts(now)
+	if err != nil {
+		return err
+	}
+
+	ownerPayouts := map[string][]my_models.Payout{}
+	owners := []string{}
+	for _, payout := range payouts {
+		reservation, err := s.ReservationRepo.GetReservationById(payout.ReservationId)
+		if err != nil {
+			logger.Error("Service: Error getting reservation of payout ", payout.Id, ": ", err)
+			continue
+		}
+		if reservation.Status != "Paid" {
+			if err := s.Repo.CancelPayout(payout.Id); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, ok := ownerPayouts[payout.OwnerId]; !ok {
+			owners = append(owners, payout.OwnerId)
+		}
+		ownerPayouts[payout.OwnerId] = append(ownerPayouts[payout.OwnerId], payout)
+	}
+
+	for _, ownerId := range owners {
+		if err := s.releaseBatch(ownerId, ownerPayouts[ownerId]); err != nil {
+			logger.Error("Service: Error releasing payouts of owner ", ownerId, ": ", err)
+		}
+	}
+
+	return nil
+}
+
+// releaseBatch is bookkeeping only: the payment gateway can only charge and
+// refund cards, so the money of the batch is transferred to the owner outside
+// of the API. Marking it Paid records that the platform owes it to the owner.
+func (s *PayoutsService) releaseBatch(ownerId string, payouts []my_models.Payout) error {
+	batch, err := s.Repo.AddBatch(ownerId, payouts)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Repo.SettleBatch(batch.Id, my_models.BatchPaid); err != nil {
+		if failErr := s.Repo.SettleBatch(batch.Id, my_models.BatchFailed); failErr != nil {
+			logger.Error("Service: Error marking payout batch ", batch.Id, " as failed: ", failErr)
+		}
+		return err
+	}
+
+	owner, err := s.UserRepo.GetUserById(ownerId)
+	if err != nil {
+		logger.Error("Service: Error getting owner ", ownerId, ": ", err)
+		return nil
+	}
+
+	message := fmt.Sprintf("A payout of %.2f for %d stays is on its way to you (batch %s)", batch.Total, batch.Count, batch.Id)
+	if err := s.NotificationService.SendMail(owner.Email, message); err != nil {
+		logger.Error("Service: Error notifying owner about payout batch ", batch.Id, ": ", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/repos/interfaces"
+	"pocketbase_go/services/mocks"
+	"testing"
+)
+
+type stubPayoutsRepo struct {
+	interfaces.IPayoutsRepo
+	added []my_models.Payout
+}
+
+func (r *stubPayoutsRepo) AddPayout(payout my_models.Payout) (my_models.Payout, error) {
+	r.added = append(r.added, payout)
+	return payout, nil
+}
+
+func TestSchedulePayoutUsesCollectedAmount(t *testing.T) {
+	reservation := my_models.ReservationModel{
+		ID:            "r1",
+		PropertyId:    "property",
+		Country:       "UY",
+		ReservedFrom:  "2027-03-01 00:00:00.000Z",
+		ReservedUntil: "2027-03-04 00:00:00.000Z",
+		CheckIn:       "2027-03-01 15:00:00.000Z",
+	}
+	charge := my_models.Payment{Id: "c1", ReservationId: "r1", Kind: my_models.ChargePayment, Amount: 432.6, Status: my_models.PaymentSucceeded}
+	refund := func(amount float64, status string) my_models.Payment {
+		return my_models.Payment{ReservationId: "r1", ChargeId: "c1", Kind: my_models.RefundPayment, Amount: amount, Status: status}
+	}
+
+	cases := []struct {
+		name     string
+		payments []my_models.Payment
+		gross    float64
+		net      float64
+	}{
+		{"paid in full", []my_models.Payment{charge}, 432.6, 320.1},
+		{"half refunded", []my_models.Payment{charge, refund(216.3, my_models.PaymentSucceeded)}, 216.3, 160.05},
+		{"failed refund", []my_models.Payment{charge, refund(216.3, my_models.PaymentFailed)}, 432.6, 320.1},
+		{"fully refunded", []my_models.Payment{charge, refund(432.6, my_models.PaymentPending)}, 0, 0},
+		{"charge being settled", []my_models.Payment{{ReservationId: "r1", Kind: my_models.ChargePayment, Amount: 432.6, Status: my_models.PaymentPending}}, 0, 0},
+		{"nothing charged", nil, 0, 0},
+	}
+	for _, c := range cases {
+		repo := &stubPayoutsRepo{}
+		service := PayoutsService{
+			Repo:           repo,
+			PaymentsRepo:   mocks.NewMockPaymentsRepo(c.payments...),
+			PropertiesRepo: stubPropertyRepo{property: my_models.Property{Owner: "owner"}},
+			PricingService: newTestPricingService(my_models.Property{BookingPrice: 100, CleaningFee: 30}, nil),
+		}
+
+		_, err := service.schedulePayout(reservation)
+		if c.gross == 0 {
+			if err == nil || len(repo.added) != 0 {
+				t.Errorf("%s: expected no payout, got %v", c.name, repo.added)
+			}
+			continue
+		}
+		if err != nil || len(repo.added) != 1 {
+			t.Fatalf("%s: expected a payout, got %v", c.name, err)
+		}
+		if payout := repo.added[0]; payout.Gross != c.gross || payout.Net != c.net || payout.OwnerId != "owner" {
+			t.Errorf("%s: expected gross %v and net %v, got %+v", c.name, c.gross, c.net, payout)
+		}
+	}
+}
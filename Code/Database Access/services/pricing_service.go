@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/repos/interfaces"
+	"time"
+)
+
+// PricingService is the single place where stay prices are computed.
+// Payments, refunds and income reports all rely on its quotes.
+type PricingService struct {
+	PropertiesRepo       interfaces.IPropertyRepo
+	SettingsRepo         interfaces.ISettingsRepo
+	PriceRulesRepo       interfaces.IPriceRulesRepo
+	serviceFeePercentage float64
+	taxPercentage        float64
+	commissionPercentage float64
+}
+
+func (s *PricingService) SetConfigValues(serviceFeePercentage float64, taxPercentage float64, commissionPercentage float64) {
+	s.serviceFeePercentage = serviceFeePercentage
+	s.taxPercentage = taxPercentage
+	s.commissionPercentage = commissionPercentage
+}
+
+func (s *PricingService) Quote(propertyId string, fromDate time.Time, untilDate time.Time, country string) (my_models.PriceQuote, error) {
+	logger.Info("Service: Quoting property ", propertyId)
+	fromDate = truncateToDay(fromDate)
+	untilDate = truncateToDay(untilDate)
+
+	nights := int(untilDate.Sub(fromDate).Hours() / 24)
+	if nights < 1 {
+		return my_models.PriceQuote{}, fmt.Errorf("a stay must be at least one night long")
+	}
+
+	property, err := s.PropertiesRepo.GetPropertyById(propertyId)
+	if err != nil {
+		logger.Error("Service: Error in Quote: ", err)
+		return my_models.PriceQuote{}, err
+	}
+
+	rules, err := s.PriceRulesRepo.GetPriceRules(propertyId)
+	if err != nil {
+		logger.Error("Service: Error in Quote: ", err)
+		return my_models.PriceQuote{}, err
+	}
+
+	// The rule that applies to the check-in night decides the minimum stay.
+	minNights := 0
+	if rule := my_models.ApplicablePriceRule(fromDate, rules); rule != nil {
+		minNights = rule.MinNights
+	}
+
+	nightlyRates := make([]my_models.NightlyRate, 0, nights)
+	subtotal := 0.0
+	for night := fromDate; night.Before(untilDate); night = night.AddDate(0, 0, 1) {
+		price := float64(property.BookingPrice)
+		if rule := my_models.ApplicablePriceRule(night, rules); rule != nil {
+			price = rule.Price
+		}
+		nightlyRates = append(nightlyRates, my_models.NightlyRate{Date: night.Format(time.DateOnly), Price: price})
+		subtotal += price
+	}
+
+	policy, err := s.CancellationPolicy(property, country)
+	if err != nil {
+		logger.Error("Service: Error in Quote: ", err)
+		return my_models.PriceQuote{}, err
+	}
+
+	subtotal = my_models.RoundPrice(subtotal)
+	cleaningFee := my_models.RoundPrice(property.CleaningFee)
+	serviceFee := my_models.RoundPrice(subtotal * s.serviceFeePercentage / 100)
+	taxes := my_models.RoundPrice((subtotal + cleaningFee) * s.taxPercentage / 100)
+
+	return my_models.PriceQuote{
+		PropertyId:         propertyId,
+		ReservedFrom:       fromDate.Format(time.DateOnly),
+		ReservedUntil:      untilDate.Format(time.DateOnly),
+		Nights:             nights,
+		MinNights:          minNights,
+		NightlyRates:       nightlyRates,
+		Subtotal:           subtotal,
+		CleaningFee:        cleaningFee,
+		ServiceFee:         serviceFee,
+		Taxes:              taxes,
+		Total:              my_models.RoundPrice(subtotal + cleaningFee + serviceFee + taxes),
+		CancellationPolicy: policy,
+	}, nil
+}
+
+func (s *PricingService) QuoteReservation(reservation my_models.ReservationModel) (my_models.PriceQuote, error) {
+	fromDate, err := my_models.ParseReservationDate(reservation.ReservedFrom)
+	if err != nil {
+		return my_models.PriceQuote{}, err
+	}
+	untilDate, err := my_models.ParseReservationDate(reservation.ReservedUntil)
+	if err != nil {
+		return my_models.PriceQuote{}, err
+	}
+
+	return s.Quote(reservation.PropertyId, fromDate, untilDate, reservation.Country)
+}
+
+// OwnerEarnings is the part of the amount collected for a quoted stay the owner
+// is paid out once the platform commission and fees are taken.
+func (s *PricingService) OwnerEarnings(quote my_models.PriceQuote, collected float64) my_models.OwnerEarnings {
+	return my_models.NewOwnerEarnings(quote, collected, s.commissionPercentage)
+}
+
+// CancellationPolicy returns the policy chosen by the owner of the property, or
+// the one built from the country settings when the owner did not pick any.
+func (s *PricingService) CancellationPolicy(property my_models.Property, country string) (my_models.CancellationPolicy, error) {
+	if property.CancellationPolicy != nil {
+		return *property.CancellationPolicy, nil
+	}
+
+	cancellationDays, err := s.SettingsRepo.GetCancellationDays(country)
+	if err != nil {
+		return my_models.CancellationPolicy{}, err
+	}
+
+	refundPercentage, err := s.SettingsRepo.GetRefundPercentage(country)
+	if err != nil {
+		return my_models.CancellationPolicy{}, err
+	}
+
+	return my_models.NewCountryCancellationPolicy(cancellationDays, refundPercentage), nil
+}
+
+func truncateToDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
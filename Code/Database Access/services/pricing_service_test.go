@@ -0,0 +1,158 @@
+package services
+
+import (
+	"os"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/repos/interfaces"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// The logger writes to ./log, keep it out of the source tree
+	dir, err := os.MkdirTemp("", "services")
+	if err == nil && os.Chdir(dir) == nil {
+		logger.Initialize("services_test.log")
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+type stubPropertyRepo struct {
+	interfaces.IPropertyRepo
+	property my_models.Property
+}
+
+func (r stubPropertyRepo) GetPropertyById(id string) (my_models.Property, error) {
+	return r.property, nil
+}
+
+type stubSettingsRepo struct {
+	interfaces.ISettingsRepo
+	cancellationDays int
+	refundPercentage float64
+}
+
+func (r stubSettingsRepo) GetCancellationDays(countryCode string) (int, error) {
+	return r.cancellationDays, nil
+}
+
+func (r stubSettingsRepo) GetRefundPercentage(countryCode string) (float64, error) {
+	return r.refundPercentage, nil
+}
+
+type stubPriceRulesRepo struct {
+	interfaces.IPriceRulesRepo
+	rules []my_models.PriceRule
+}
+
+func (r stubPriceRulesRepo) GetPriceRules(propertyId string) ([]my_models.PriceRule, error) {
+	return r.rules, nil
+}
+
+func newTestPricingService(property my_models.Property, rules []my_models.PriceRule) *PricingService {
+	service := &PricingService{
+		PropertiesRepo: stubPropertyRepo{property: property},
+		SettingsRepo:   stubSettingsRepo{cancellationDays: 7, refundPercentage: 50},
+		PriceRulesRepo: stubPriceRulesRepo{rules: rules},
+	}
+	service.SetConfigValues(10, 22, 3)
+	return service
+}
+
+func date(value string) time.Time {
+	parsed, _ := time.Parse(time.DateOnly, value)
+	return parsed
+}
+
+func TestQuoteBreakdown(t *testing.T) {
+	service := newTestPricingService(my_models.Property{BookingPrice: 100, CleaningFee: 30}, nil)
+
+	quote, err := service.Quote("property", date("2027-03-01"), date("2027-03-04"), "UY")
+	if err != nil {
+		t.Fatalf("expected a quote, got %v", err)
+	}
+
+	expected := map[string][2]float64{
+		"nights":        {float64(quote.Nights), 3},
+		"nightly rates": {float64(len(quote.NightlyRates)), 3},
+		"subtotal":      {quote.Subtotal, 300},
+		"cleaning fee":  {quote.CleaningFee, 30},
+		"service fee":   {quote.ServiceFee, 30},
+		"taxes":         {quote.Taxes, 72.6},
+		"total":         {quote.Total, 432.6},
+	}
+	for name, values := range expected {
+		if values[0] != values[1] {
+			t.Errorf("%s: expected %v, got %v", name, values[1], values[0])
+		}
+	}
+
+	if quote.CancellationPolicy.Type != my_models.CountryCancellationPolicy {
+		t.Errorf("expected the country policy for a property without one, got %s", quote.CancellationPolicy.Type)
+	}
+}
+
+func TestQuoteUsesPropertyCancellationPolicy(t *testing.T) {
+	policy := my_models.CancellationPolicy{Type: my_models.StrictCancellationPolicy}
+	policy.Normalize()
+	service := newTestPricingService(my_models.Property{BookingPrice: 100, CancellationPolicy: &policy}, nil)
+
+	quote, err := service.Quote("property", date("2027-03-01"), date("2027-03-02"), "UY")
+	if err != nil {
+		t.Fatalf("expected a quote, got %v", err)
+	}
+	if quote.CancellationPolicy.Type != my_models.StrictCancellationPolicy {
+		t.Errorf("expected the property policy, got %s", quote.CancellationPolicy.Type)
+	}
+}
+
+func TestQuoteRejectsEmptyStays(t *testing.T) {
+	service := newTestPricingService(my_models.Property{BookingPrice: 100}, nil)
+
+	cases := map[string][2]string{
+		"same day":       {"2027-03-01", "2027-03-01"},
+		"inverted dates": {"2027-03-04", "2027-03-01"},
+	}
+	for name, dates := range cases {
+		if _, err := service.Quote("property", date(dates[0]), date(dates[1]), "UY"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestQuoteAppliesPriceRulesPerNight(t *testing.T) {
+	rules := []my_models.PriceRule{
+		{Type: my_models.SeasonPriceRule, DateFrom: "2027-01-01", DateTo: "2027-01-31", Price: 150, MinNights: 3},
+		{Type: my_models.SpecialDayPriceRule, DateFrom: "2027-01-02", DateTo: "2027-01-02", Price: 300},
+	}
+	service := newTestPricingService(my_models.Property{BookingPrice: 100}, rules)
+
+	quote, err := service.Quote("property", date("2026-12-31"), date("2027-01-03"), "UY")
+	if err != nil {
+		t.Fatalf("expected a quote, got %v", err)
+	}
+
+	expected := []float64{100, 150, 300}
+	for i, rate := range quote.NightlyRates {
+		if rate.Price != expected[i] {
+			t.Errorf("night %s: expected %v, got %v", rate.Date, expected[i], rate.Price)
+		}
+	}
+	if quote.Subtotal != 550 {
+		t.Errorf("expected subtotal 550, got %v", quote.Subtotal)
+	}
+	if quote.MinNights != 0 {
+		t.Errorf("expected the check-in night rule to set no minimum, got %d", quote.MinNights)
+	}
+
+	seasonQuote, err := service.Quote("property", date("2027-01-10"), date("2027-01-12"), "UY")
+	if err != nil {
+		t.Fatalf("expected a quote, got %v", err)
+	}
+	if err := seasonQuote.CheckMinNights(); err == nil {
+		t.Errorf("expected a 2 night stay to break the 3 night season minimum")
+	}
+}
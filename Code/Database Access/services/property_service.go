@@ -9,8 +9,9 @@ import (
 )
 
 type PropertyService struct {
-	Repo     interfaces.IPropertyRepo
-	UserRepo interfaces.IUserRepo
+	Repo           interfaces.IPropertyRepo
+	UserRepo       interfaces.IUserRepo
+	PriceRulesRepo interfaces.IPriceRulesRepo
 }
 
 func (r *PropertyService) AddUnavailableDates(propertyId string, dates []my_models.DateRange, userToken string) error {
@@ -67,6 +68,129 @@ func (r *PropertyService) RemoveUnavailableDates(propertyId string, date my_mode
 	return fmt.Errorf("provided token does not belong to an owner user")
 }
 
+func (r *PropertyService) UpdateInstantBook(propertyId string, instantBook bool, rules my_models.InstantBookRules, userToken string) error {
+	logger.Info("Service: Updating instant book settings")
+	if err := r.validateOwner(propertyId, userToken); err != nil {
+		return err
+	}
+
+	if rules.MaxNights < 0 || rules.MinPreviousStays < 0 {
+		return fmt.Errorf("instant book rules must not be negative")
+	}
+
+	return r.Repo.UpdateInstantBook(propertyId, instantBook, rules)
+}
+
+func (r *PropertyService) UpdateBookingRules(propertyId string, rules my_models.BookingRules, userToken string) error {
+	logger.Info("Service: Updating booking rules")
+	if err := r.validateOwner(propertyId, userToken); err != nil {
+		return err
+	}
+
+	if err := rules.Validate(); err != nil {
+		logger.Error("Service: Invalid booking rules: ", err)
+		return err
+	}
+
+	return r.Repo.UpdateBookingRules(propertyId, rules)
+}
+
+// UpdateCancellationPolicy sets the policy of the property, a nil policy makes
+// it follow the country cancellation settings again.
+func (r *PropertyService) UpdateCancellationPolicy(propertyId string, policy *my_models.CancellationPolicy, userToken string) error {
+	logger.Info("Service: Updating cancellation policy")
+	if err := r.validateOwner(propertyId, userToken); err != nil {
+		return err
+	}
+
+	if policy != nil {
+		if err := policy.Normalize(); err != nil {
+			logger.Error("Service: Invalid cancellation policy: ", err)
+			return err
+		}
+	}
+
+	return r.Repo.UpdateCancellationPolicy(propertyId, policy)
+}
+
+func (r *PropertyService) UpdatePaymentTerms(propertyId string, terms my_models.PaymentTerms, userToken string) error {
+	logger.Info("Service: Updating payment terms")
+	if err := r.validateOwner(propertyId, userToken); err != nil {
+		return err
+	}
+
+	if err := terms.Validate(); err != nil {
+		logger.Error("Service: Invalid payment terms: ", err)
+		return err
+	}
+
+	return r.Repo.UpdatePaymentTerms(propertyId, terms)
+}
+
+func (r *PropertyService) AddPriceRule(propertyId string, rule my_models.PriceRule, userToken string) (string, error) {
+	logger.Info("Service: Adding price rule")
+	if err := r.validateOwner(propertyId, userToken); err != nil {
+		return "", err
+	}
+
+	if err := rule.Validate(); err != nil {
+		logger.Error("Service: Invalid price rule: ", err)
+		return "", err
+	}
+
+	return r.PriceRulesRepo.AddPriceRule(propertyId, rule)
+}
+
+func (r *PropertyService) GetPriceRules(propertyId string) ([]my_models.PriceRule, error) {
+	logger.Info("Service: Getting price rules")
+	if _, err := r.Repo.GetPropertyById(propertyId); err != nil {
+		return nil, err
+	}
+
+	return r.PriceRulesRepo.GetPriceRules(propertyId)
+}
+
+func (r *PropertyService) RemovePriceRule(propertyId string, ruleId string, userToken string) error {
+	logger.Info("Service: Removing price rule")
+	if err := r.validateOwner(propertyId, userToken); err != nil {
+		return err
+	}
+
+	return r.PriceRulesRepo.RemovePriceRule(propertyId, ruleId)
+}
+
+// validateOwner checks that the token belongs to an Owner user and that the
+// property is theirs.
+func (r *PropertyService) validateOwner(propertyId string, userToken string) error {
+	roles, userId, err := r.UserRepo.Login(userToken)
+	if err != nil {
+		return err
+	}
+
+	isOwner := false
+	for _, role := range roles {
+		if role == "Owner" {
+			isOwner = true
+		}
+	}
+	if !isOwner {
+		logger.Error("Service: User is not an owner")
+		return fmt.Errorf("provided token does not belong to an owner user")
+	}
+
+	property, err := r.Repo.GetPropertyById(propertyId)
+	if err != nil {
+		return err
+	}
+
+	if property.Owner != userId {
+		logger.Error("Service: User is not the owner of the property")
+		return fmt.Errorf("user is not the owner of this property")
+	}
+
+	return nil
+}
+
 func (r *PropertyService) AddProperty(property my_models.Property, userToken string) (string, error) {
 	logger.Info("Service: Adding property")
 	roles, userId, err := r.UserRepo.Login(userToken)
@@ -74,6 +198,23 @@ func (r *PropertyService) AddProperty(property my_models.Property, userToken str
 		return "", err
 	}
 
+	if err := property.BookingRules.Validate(); err != nil {
+		logger.Error("Service: Invalid booking rules: ", err)
+		return "", err
+	}
+
+	if property.CancellationPolicy != nil {
+		if err := property.CancellationPolicy.Normalize(); err != nil {
+			logger.Error("Service: Invalid cancellation policy: ", err)
+			return "", err
+		}
+	}
+
+	if err := property.PaymentTerms.Validate(); err != nil {
+		logger.Error("Service: Invalid payment terms: ", err)
+		return "", err
+	}
+
 	for _, role := range roles {
 		if role == "Owner" {
 			property.Owner = userId
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/repos/interfaces"
+	serviceInterfaces "pocketbase_go/services/interfaces"
+	"time"
+)
+
+const (
+	preArrivalReminderHours    = 48
+	checkOutReminderHour       = 8
+	reviewRequestDelayHours    = 3
+	dueRemindersBatchSize      = 100
+	defaultNotificationRetries = 5
+)
+
+// ReminderService stores the reminders of a reservation as scheduled
+// notifications when it changes state, and sends them once they are due.
+type ReminderService struct {
+	Repo                interfaces.IScheduledNotificationsRepo
+	SettingsRepo        interfaces.ISettingsRepo
+	PropertiesRepo      interfaces.IPropertyRepo
+	NotificationService serviceInterfaces.INotificationService
+	paymentWarningHours int
+	maxAttempts         int
+}
+
+func (s *ReminderService) SetConfigValues(paymentWarningHours int, maxAttempts int) {
+	s.paymentWarningHours = paymentWarningHours
+	s.maxAttempts = maxAttempts
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = defaultNotificationRetries
+	}
+}
+
+// ScheduleApprovalReminders warns the tenant before the payment deadline of the
+// reservation country runs out.
+func (s *ReminderService) ScheduleApprovalReminders(reservation my_models.ReservationModel) error {
+	deadlineHours, err := s.SettingsRepo.GetPaymentDeadlineHours(reservation.Country)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().UTC().Add(time.Duration(deadlineHours) * time.Hour)
+	message := fmt.Sprintf("Your reservation %s will be cancelled if it is not paid before %s UTC", reservation.ID, deadline.Format(time.DateTime))
+	return s.schedule(reservation, my_models.PaymentDueReminder, deadline.Add(-time.Duration(s.paymentWarningHours)*time.Hour), message)
+}
+
+// SchedulePaidReminders replaces the payment reminder with the ones of the stay:
+// check-in instructions before arrival and a check out reminder on the last morning.
+func (s *ReminderService) SchedulePaidReminders(reservation my_models.ReservationModel) error {
+	if err := s.Repo.DeletePendingForReservation(reservation.ID); err != nil {
+		return err
+	}
+
+	property, err := s.PropertiesRepo.GetPropertyById(reservation.PropertyId)
+	if err != nil {
+		return err
+	}
+
+	fromDate, err := my_models.ParseReservationDate(reservation.ReservedFrom)
+	if err != nil {
+		return err
+	}
+	untilDate, err := my_models.ParseReservationDate(reservation.ReservedUntil)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Your stay at %s starts on %s.", property.Name, fromDate.Format(time.DateOnly))
+	if property.CheckInInstructions != "" {
+		message += " Check-in instructions: " + property.CheckInInstructions
+	}
+	if err := s.schedule(reservation, my_models.PreArrivalReminder, fromDate.Add(-preArrivalReminderHours*time.Hour), message); err != nil {
+		return err
+	}
+
+	message = fmt.Sprintf("Your stay at %s ends today, please remember to check out", property.Name)
+	return s.schedule(reservation, my_models.CheckOutReminder, untilDate.Add(checkOutReminderHour*time.Hour), message)
+}
+
+// ScheduleCheckOutReminders asks the tenant to review the stay a few hours after leaving.
+func (s *ReminderService) ScheduleCheckOutReminders(reservation my_models.ReservationModel) error {
+	if err := s.Repo.DeletePendingForReservation(reservation.ID); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Thanks for staying with us! Tell us how your stay of reservation %s went by leaving a review", reservation.ID)
+	return s.schedule(reservation, my_models.ReviewRequestReminder, time.Now().UTC().Add(reviewRequestDelayHours*time.Hour), message)
+}
+
+// CancelReminders drops the pending reminders of a reservation that will not take place.
+func (s *ReminderService) CancelReminders(reservationId string) error {
+	return s.Repo.DeletePendingForReservation(reservationId)
+}
+
+// SendDueReminders sends every due reminder. A reminder is only marked as sent
+// after the mail goes out, so a failure is retried on the next run until it
+// reaches the maximum attempts (at-least-once delivery).
+func (s *ReminderService) SendDueReminders() error {
+	notifications, err := s.Repo.GetDueNotifications(time.Now(), s.maxAttempts, dueRemindersBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range notifications {
+		if err := s.NotificationService.SendMail(notification.Email, notification.Message); err != nil {
+			logger.Error("Service: Error sending reminder ", notification.DedupeKey, ": ", err)
+			if err := s.Repo.MarkFailed(notification.Id, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.Repo.MarkSent(notification.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ReminderService) schedule(reservation my_models.ReservationModel, kind string, dueAt time.Time, message string) error {
+	// Reminders whose time already passed go out on the next run
+	if dueAt.Before(time.Now()) {
+		dueAt = time.Now()
+	}
+
+	return s.Repo.Schedule(my_models.ScheduledNotification{
+		DedupeKey:     my_models.ReminderDedupeKey(kind, reservation.ID),
+		Kind:          kind,
+		ReservationId: reservation.ID,
+		Email:         reservation.Email,
+		Message:       message,
+		DueAt:         dueAt.UTC().Format(my_models.PocketTimeLayout),
+	})
+}
@@ -6,16 +6,20 @@ import (
 	"pocketbase_go/logger"
 	"pocketbase_go/my_models"
 	interfaces "pocketbase_go/repos/interfaces"
+	serviceInterfaces "pocketbase_go/services/interfaces"
 	"strings"
 	"time"
 )
 
 type ReportsService struct {
-	ReservationRepo interfaces.IReservationRepo
-	PropertiesRepo  interfaces.IPropertyRepo
-	UsersRepo       interfaces.IUserRepo
-	ReportsRepo     mongoInter.ReportsRepo
-	SensorRepo      interfaces.ISensorRepo
+	ReservationRepo       interfaces.IReservationRepo
+	PropertiesRepo        interfaces.IPropertyRepo
+	UsersRepo             interfaces.IUserRepo
+	ReportsRepo           mongoInter.ReportsRepo
+	SensorRepo            interfaces.ISensorRepo
+	PricingService        serviceInterfaces.IPricingService
+	HostCancellationsRepo interfaces.IHostCancellationsRepo
+	PaymentSchedulesRepo  interfaces.IPaymentSchedulesRepo
 }
 
 func (c *ReportsService) GetLatestSensorReport(sensorId string) (mongo_models.SensorReport, error) {
@@ -62,8 +66,7 @@ func (c *ReportsService) ValidateSecurityReport(report mongo_models.SensorReport
 func (c *ReportsService) GetPropertiesIncomes(property_id string, fromDate time.Time, untilDate time.Time) (my_models.IncomeReport, error) {
 	logger.Info("Service: Getting properties incomes")
 
-	property, err := c.PropertiesRepo.GetPropertyById(property_id)
-	if err != nil {
+	if _, err := c.PropertiesRepo.GetPropertyById(property_id); err != nil {
 		logger.Error("Service: error retrieving property with id ", property_id, ": ", err)
 		return my_models.IncomeReport{}, err
 	}
@@ -77,7 +80,7 @@ func (c *ReportsService) GetPropertiesIncomes(property_id string, fromDate time.
 		ReservedUntil: &untilDateStr,
 	}
 
-	bookings, err := c.ReservationRepo.GetFilteredReservations(filter)
+	bookings, _, err := c.ReservationRepo.GetFilteredReservations(filter)
 	if err != nil {
 		logger.Error("Service: error retrieving reservations for property ", property_id, ": ", err)
 		return my_models.IncomeReport{}, err
@@ -85,19 +88,57 @@ func (c *ReportsService) GetPropertiesIncomes(property_id string, fromDate time.
 
 	bookingsReports := make([]my_models.BookingIncomeReport, 0)
 	for _, booking := range bookings {
-		bookingsReports = append(bookingsReports, makeBookingIncomeReport(booking, float64(property.BookingPrice)))
+		quote, err := c.PricingService.QuoteReservation(booking)
+		if err != nil {
+			logger.Error("Service: error quoting reservation ", booking.ID, ": ", err)
+			return my_models.IncomeReport{}, err
+		}
+		installments, err := c.PaymentSchedulesRepo.GetInstallments(booking.ID)
+		if err != nil {
+			logger.Error("Service: error retrieving payments of reservation ", booking.ID, ": ", err)
+			return my_models.IncomeReport{}, err
+		}
+		earnings := c.PricingService.OwnerEarnings(quote, quote.Total)
+		bookingsReports = append(bookingsReports, makeBookingIncomeReport(booking, quote, earnings, installments))
+	}
+
+	collected, pending := 0.0, 0.0
+	commission, fees, net := 0.0, 0.0, 0.0
+	for _, booking := range bookingsReports {
+		collected += booking.AmountPaid
+		pending += booking.AmountPending
+		commission += booking.Commission
+		fees += booking.Fees
+		net += booking.Net
 	}
 
 	logger.Info("Service: Got properties incomes successfully")
 	return my_models.IncomeReport{
 		PropertyId:      property_id,
 		TotalIncome:     sumBookingsIncome(bookingsReports),
+		TotalCommission: my_models.RoundPrice(commission),
+		TotalFees:       my_models.RoundPrice(fees),
+		TotalNet:        my_models.RoundPrice(net),
+		TotalCollected:  my_models.RoundPrice(collected),
+		TotalPending:    my_models.RoundPrice(pending),
 		FromDate:        fromDate,
 		ToDate:          untilDate,
 		BookingsReports: bookingsReports,
 	}, nil
 }
 
+func (c *ReportsService) GetFrequentHostCancellers(fromDate time.Time, untilDate time.Time, minCancellations int) ([]my_models.HostCancellationsReportItem, error) {
+	logger.Info("Service: Getting frequent host cancellers report")
+	items, err := c.HostCancellationsRepo.GetFrequentCancellers(fromDate, untilDate, minCancellations)
+	if err != nil {
+		logger.Error("Service: error retrieving host cancellations: ", err)
+		return nil, err
+	}
+
+	logger.Info("Service: Got frequent host cancellers successfully")
+	return items, nil
+}
+
 func (c *ReportsService) GetOccupations(fromDate time.Time, untilDate time.Time) ([]my_models.OccupationsReportItem, error) {
 	logger.Info("Service: Getting occupations report")
 	fromDateStr := fromDate.Format(time.DateOnly)
@@ -172,15 +213,28 @@ func (c *ReportsService) GetPropertiesRanking(fromDate time.Time, untilDate time
 	return propertiesRanking, nil
 }
 
-func makeBookingIncomeReport(booking my_models.ReservationModel, propertyPrice float64) my_models.BookingIncomeReport {
+// makeBookingIncomeReport splits the income of a booking into what was already
+// collected and what is still to be charged while the booking is active, and
+// its gross income into the platform commission and fees and the owner's net.
+func makeBookingIncomeReport(booking my_models.ReservationModel, quote my_models.PriceQuote, earnings my_models.OwnerEarnings, installments []my_models.PaymentInstallment) my_models.BookingIncomeReport {
 	fromDate, _ := time.Parse(my_models.PocketTimeLayout, booking.ReservedFrom)
 	untilDate, _ := time.Parse(my_models.PocketTimeLayout, booking.ReservedUntil)
 
-	daysCount := untilDate.Sub(fromDate).Hours() / 24
+	amountPaid := my_models.AmountPaid(booking, installments, quote.Total)
+	amountPending := 0.0
+	if booking.Status == "Approved" || booking.Status == "PartiallyPaid" {
+		amountPending = my_models.RoundPrice(quote.Total - amountPaid)
+	}
 
 	return my_models.BookingIncomeReport{
 		BookingId:      booking.ID,
-		Income:         propertyPrice * daysCount,
+		Income:         earnings.Gross,
+		Commission:     earnings.Commission,
+		Fees:           earnings.Fees,
+		Net:            earnings.Net,
+		Status:         booking.Status,
+		AmountPaid:     amountPaid,
+		AmountPending:  amountPending,
 		FromDate:       fromDate,
 		ToDate:         untilDate,
 		TenantEmail:    booking.Email,
@@ -1,61 +1,189 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"pocketbase_go/gateways"
 	"pocketbase_go/logger"
 	"pocketbase_go/my_models"
 	interfaces "pocketbase_go/repos/interfaces"
+	serviceInterfaces "pocketbase_go/services/interfaces"
+	"strings"
 	"time"
 )
 
-const autoCancelDays = 3
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
 
 type ReservationService struct {
-	ReservationRepo interfaces.IReservationRepo
-	UserRepo        interfaces.IUserRepo
-	SettingsRepo    interfaces.ISettingsRepo
-	PropertiesRepo  interfaces.IPropertyRepo
-	refundUrl       string
+	ReservationRepo                   interfaces.IReservationRepo
+	UserRepo                          interfaces.IUserRepo
+	SettingsRepo                      interfaces.ISettingsRepo
+	PropertiesRepo                    interfaces.IPropertyRepo
+	NotificationService               serviceInterfaces.INotificationService
+	PricingService                    serviceInterfaces.IPricingService
+	HostCancellationsRepo             interfaces.IHostCancellationsRepo
+	ReminderService                   serviceInterfaces.IReminderService
+	GuestsRepo                        interfaces.IGuestsRepo
+	PaymentSchedulesRepo              interfaces.IPaymentSchedulesRepo
+	PaymentsRepo                      interfaces.IPaymentsRepo
+	Gateway                           gateways.PaymentGateway
+	ownerResponseSlaHours             int
+	hostCancellationPenaltyPercentage float64
+	noShowCutoffHours                 int
 }
 
-func (s *ReservationService) SetConfigValues(refundUrl string) {
-	s.refundUrl = refundUrl
+func (s *ReservationService) SetConfigValues(ownerResponseSlaHours int, hostCancellationPenaltyPercentage float64, noShowCutoffHours int) {
+	s.ownerResponseSlaHours = ownerResponseSlaHours
+	s.hostCancellationPenaltyPercentage = hostCancellationPenaltyPercentage
+	s.noShowCutoffHours = noShowCutoffHours
 }
 
-func (s *ReservationService) CreateReservation(reservation my_models.ReservationModel) error {
+func (s *ReservationService) CreateReservation(reservation my_models.ReservationModel) (my_models.ReservationModel, error) {
 	if err := reservation.ValidateFields(); err != nil {
-		return err
+		return my_models.ReservationModel{}, err
 	}
 
 	property, err := s.PropertiesRepo.GetPropertyById(reservation.PropertyId)
 	if err != nil {
-		return err
+		return my_models.ReservationModel{}, err
 	}
 	if property.IsPendingPayment {
-		return fmt.Errorf("property %s is pending payment, reservation cannot be made", reservation.PropertyId)
+		return my_models.ReservationModel{}, fmt.Errorf("property %s is pending payment, reservation cannot be made", reservation.PropertyId)
 	}
 
-	if err := s.ReservationRepo.CreateReservation(reservation); err != nil {
-		return err
+	fromDate, err := my_models.ParseReservationDate(reservation.ReservedFrom)
+	if err != nil {
+		return my_models.ReservationModel{}, err
+	}
+	untilDate, err := my_models.ParseReservationDate(reservation.ReservedUntil)
+	if err != nil {
+		return my_models.ReservationModel{}, err
+	}
+	if err := property.BookingRules.Check(fromDate, untilDate, time.Now()); err != nil {
+		logger.Error("Service: Reservation breaks the property booking rules: ", err)
+		return my_models.ReservationModel{}, err
+	}
+
+	// Quoting up front rejects stays that break the property price rules (e.g. minimum nights).
+	quote, err := s.PricingService.QuoteReservation(reservation)
+	if err != nil {
+		return my_models.ReservationModel{}, err
+	}
+	if err := quote.CheckMinNights(); err != nil {
+		return my_models.ReservationModel{}, err
 	}
 
 	ownerEmail, err := s.UserRepo.GetPropertyOwner(reservation.PropertyId)
 	if err != nil {
-		return err
+		return my_models.ReservationModel{}, err
+	}
+
+	// Instant book is decided before the reservation is stored, so a failure
+	// here does not leave a pending row behind for the client to retry.
+	instantBook := false
+	if property.InstantBook {
+		instantBook, err = s.qualifiesForInstantBook(reservation, property, quote.Nights)
+		if err != nil {
+			return my_models.ReservationModel{}, err
+		}
+	}
+
+	reservationId, err := s.ReservationRepo.CreateReservation(reservation)
+	if err != nil {
+		return my_models.ReservationModel{}, err
+	}
+	reservation.ID = reservationId
+	reservation.Status = "Pending"
+
+	if instantBook {
+		if err := s.instantBook(reservation, ownerEmail); err == nil {
+			reservation.Status = "Approved"
+			return reservation, nil
+		}
+		// The booking is kept pending for manual approval
+		logger.Error("Service: Error instantly booking reservation ", reservation.ID, ": ", err)
 	}
 
 	if err := s.NotifyValidReservation(reservation, ownerEmail); err != nil {
+		logger.Error("Service: Error notifying about reservation ", reservation.ID, ": ", err)
+	}
+
+	return reservation, nil
+}
+
+// qualifiesForInstantBook checks the instant book rules of the property. A
+// reservation that does not meet them stays pending for manual approval.
+func (s *ReservationService) qualifiesForInstantBook(reservation my_models.ReservationModel, property my_models.Property, nights int) (bool, error) {
+	previousStays, err := s.ReservationRepo.CountCompletedStays(reservation.Email)
+	if err != nil {
+		return false, err
+	}
+
+	if err := property.InstantBookRules.Check(nights, previousStays); err != nil {
+		logger.Info("Service: Reservation for ", reservation.Email, " requires manual approval: ", err)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// instantBook approves a reservation that qualified for instant book and lets
+// the owner and the tenant know.
+func (s *ReservationService) instantBook(reservation my_models.ReservationModel, ownerEmail string) error {
+	if err := s.ReservationRepo.ApproveReservation(reservation.ID); err != nil {
 		return err
 	}
 
+	logger.Info("Service: Reservation ", reservation.ID, " approved through instant book")
+	if err := s.ReminderService.ScheduleApprovalReminders(reservation); err != nil {
+		logger.Error("Service: Error scheduling reminders of reservation ", reservation.ID, ": ", err)
+	}
+
+	ownerMessage := fmt.Sprintf("Reservation %s for your property %s was instantly booked from %s to %s", reservation.ID, reservation.PropertyId, reservation.ReservedFrom, reservation.ReservedUntil)
+	if err := s.NotificationService.SendMail(ownerEmail, ownerMessage); err != nil {
+		logger.Error("Service: Error notifying owner about instantly booked reservation: ", err)
+	}
+
+	tenantMessage := fmt.Sprintf("Your reservation %s has been approved, payment can be made now", reservation.ID)
+	if err := s.NotificationService.SendMail(reservation.Email, tenantMessage); err != nil {
+		logger.Error("Service: Error notifying tenant about approved reservation: ", err)
+	}
+
 	return nil
 }
 
-func (s *ReservationService) GetFilteredReservations(filter my_models.ReservationFilter) ([]my_models.ReservationModel, error) {
-	return s.ReservationRepo.GetFilteredReservations(filter)
+func (s *ReservationService) GetFilteredReservations(filter my_models.ReservationFilter) (my_models.ReservationsPage, error) {
+	page, size := 1, defaultPageSize
+	if filter.Page != nil && *filter.Page > 0 {
+		page = *filter.Page
+	}
+	if filter.Size != nil && *filter.Size > 0 {
+		size = min(*filter.Size, maxPageSize)
+	}
+	filter.Page, filter.Size = &page, &size
+
+	reservations, total, err := s.ReservationRepo.GetFilteredReservations(filter)
+	if err != nil {
+		return my_models.ReservationsPage{}, err
+	}
+
+	return my_models.ReservationsPage{Items: reservations, Page: page, Size: size, Total: total}, nil
+}
+
+// ExportReservations renders every reservation matching the filter, ignoring
+// pagination, as a csv or xlsx file.
+func (s *ReservationService) ExportReservations(filter my_models.ReservationFilter, format string) ([]byte, error) {
+	filter.Page, filter.Size = nil, nil
+
+	reservations, _, err := s.ReservationRepo.GetFilteredReservations(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return writeReservationsExport(s.reservationExportRows(reservations), format)
 }
 
 func (s *ReservationService) NotifyValidReservation(reservation my_models.ReservationModel, ownerEmail string) error {
@@ -79,8 +207,104 @@ func (s *ReservationService) GetOwnReservation(email string, propertyId string)
 	return s.ReservationRepo.GetOwnReservation(email, propertyId)
 }
 
+func (s *ReservationService) GetTenantReservations(email string, filter my_models.TenantReservationFilter) (my_models.TenantReservationsPage, error) {
+	if err := filter.Normalize(); err != nil {
+		return my_models.TenantReservationsPage{}, err
+	}
+
+	reservations, total, err := s.ReservationRepo.GetTenantReservations(email, filter)
+	if err != nil {
+		return my_models.TenantReservationsPage{}, err
+	}
+
+	items := make([]my_models.TenantReservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		property, err := s.PropertiesRepo.GetPropertyById(reservation.PropertyId)
+		if err != nil {
+			return my_models.TenantReservationsPage{}, err
+		}
+
+		summary := my_models.PropertySummary{Id: property.Id, Name: property.Name}
+		if len(property.Images) > 0 {
+			summary.CoverImage = property.Images[0]
+		}
+
+		amountPaid := 0.0
+		if reservation.Status == "Paid" || reservation.Status == "PartiallyPaid" {
+			quote, err := s.PricingService.QuoteReservation(reservation)
+			if err != nil {
+				return my_models.TenantReservationsPage{}, err
+			}
+			amountPaid, err = s.amountPaid(reservation, quote)
+			if err != nil {
+				return my_models.TenantReservationsPage{}, err
+			}
+		}
+
+		items = append(items, my_models.TenantReservation{ReservationModel: reservation, Property: summary, AmountPaid: amountPaid})
+	}
+
+	return my_models.TenantReservationsPage{Items: items, Page: filter.Page, Size: filter.Size, Total: total}, nil
+}
+
 func (s *ReservationService) ApproveReservation(reservationId string) error {
-	return s.ReservationRepo.ApproveReservation(reservationId)
+	reservation, err := s.ReservationRepo.GetReservationById(reservationId)
+	if err != nil {
+		return err
+	}
+
+	if reservation.Status != "Pending" {
+		return fmt.Errorf("only pending reservations can be approved, reservation %s is %s", reservationId, reservation.Status)
+	}
+
+	if err := s.ReservationRepo.ApproveReservation(reservationId); err != nil {
+		return err
+	}
+	if err := s.ReminderService.ScheduleApprovalReminders(reservation); err != nil {
+		logger.Error("Service: Error scheduling reminders of reservation ", reservationId, ": ", err)
+	}
+
+	message := fmt.Sprintf("Your reservation %s has been approved, payment can be made now", reservationId)
+	return s.NotificationService.SendMail(reservation.Email, message)
+}
+
+func (s *ReservationService) RejectReservation(reservationId string, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("a reason must be provided to reject a reservation")
+	}
+
+	reservation, err := s.ReservationRepo.GetReservationById(reservationId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ReservationRepo.RejectReservation(reservationId, reason); err != nil {
+		return err
+	}
+
+	logger.Info("Service: Reservation ", reservationId, " rejected")
+	message := fmt.Sprintf("Your reservation %s has been rejected: %s", reservationId, reason)
+	return s.NotificationService.SendMail(reservation.Email, message)
+}
+
+// ValidatePropertyOwner checks that the user owns the property the reservation belongs to.
+func (s *ReservationService) ValidatePropertyOwner(reservationId string, userId string) error {
+	reservation, err := s.ReservationRepo.GetReservationById(reservationId)
+	if err != nil {
+		return err
+	}
+
+	property, err := s.PropertiesRepo.GetPropertyById(reservation.PropertyId)
+	if err != nil {
+		return err
+	}
+
+	if property.Owner != userId {
+		logger.Error("Service: User ", userId, " is not the owner of property ", property.Id)
+		return fmt.Errorf("user is not the owner of the reserved property")
+	}
+
+	return nil
 }
 
 func (s *ReservationService) RemoveReservation(reservationId string) error {
@@ -97,11 +321,11 @@ func (s *ReservationService) CancelReservation(email string, reservationId strin
 		return 0, fmt.Errorf("user %s is not allowed to cancel reservation %s", email, reservationId)
 	}
 
-	cancellationDaysLimit, err := s.SettingsRepo.GetCancellationDays(reservation.Country)
-	if err != nil {
-		return 0, err
+	if reservation.Status != "Approved" && reservation.Status != "PartiallyPaid" && reservation.Status != "Paid" {
+		return 0, fmt.Errorf("only approved or paid reservations can be cancelled, reservation is %s", reservation.Status)
 	}
-	reservationStartDate, err := time.Parse(my_models.PocketTimeLayout, reservation.ReservedFrom)
+
+	reservationStartDate, err := my_models.ParseReservationDate(reservation.ReservedFrom)
 	if err != nil {
 		return 0, err
 	}
@@ -112,87 +336,439 @@ func (s *ReservationService) CancelReservation(email string, reservationId strin
 		return 0, fmt.Errorf("reservation %s starting date already passed, it cannot be cancelled", reservationId)
 	}
 
-	refundPercentage = 100.0
-	if timeDifference.Hours() < float64(cancellationDaysLimit*24) {
-		refundPercentage, err = s.SettingsRepo.GetRefundPercentage(reservation.Country)
-		if err != nil {
-			return 0, err
-		}
+	quote, err := s.PricingService.QuoteReservation(reservation)
+	if err != nil {
+		return 0, err
+	}
+
+	refundPercentage = quote.CancellationPolicy.RefundPercentage(timeDifference.Hours())
+	if refundPercentage < 100 {
 		logger.Error("Service: cancellation date is beyond permmitted date, only ", refundPercentage, " percent will be refunded")
 	}
 
-	property, err := s.PropertiesRepo.GetPropertyById(reservation.PropertyId)
+	// As with host cancellations, the reservation is cancelled before any money
+	// is returned, so cancelling it again never refunds twice.
+	logger.Info("Service: User ", email, "is trying to cancel reservation ", reservationId)
+	if err := s.ReservationRepo.CancelReservation(reservationId); err != nil {
+		return 0, err
+	}
+	s.cancelReminders(reservationId)
+	s.cancelPendingInstallments(reservationId)
+
+	refundable, err := s.refundableAmount(reservationId)
 	if err != nil {
 		return 0, err
 	}
 
-	pricePerDay := property.BookingPrice
-	totalPrice := pricePerDay * int(timeDifference.Hours()/24)
+	refund := my_models.RoundPrice(refundable * refundPercentage / 100)
+	if refund > 0 {
+		if _, err := s.refund(reservationId, refund); err != nil {
+			// The failed attempt is in the ledger, an Admin retries it through the refunds endpoint
+			return 0, fmt.Errorf("reservation %s was cancelled but its refund failed: %w", reservationId, err)
+		}
+	}
+
+	return refundPercentage, nil
+}
 
-	refund := float64(totalPrice) * refundPercentage / 100
-	requestBody := map[string]interface{}{
-		"amount": refund,
+// HostCancelReservation cancels a reservation on behalf of the property owner or
+// an Admin. The tenant always gets a full refund and the owner is charged a
+// penalty. When blockDates is set the stay dates become unavailable.
+func (s *ReservationService) HostCancelReservation(reservationId string, reason string, cancelledBy string, blockDates bool) (refund float64, err error) {
+	if strings.TrimSpace(reason) == "" {
+		return 0, fmt.Errorf("a cancellation reason must be provided")
 	}
 
-	bodyJSON, err := json.Marshal(requestBody)
+	reservation, err := s.ReservationRepo.GetReservationById(reservationId)
 	if err != nil {
 		return 0, err
 	}
 
-	req, err := http.NewRequest("POST", s.refundUrl, bytes.NewBuffer(bodyJSON))
+	property, err := s.PropertiesRepo.GetPropertyById(reservation.PropertyId)
 	if err != nil {
 		return 0, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	if reservation.Status != "Approved" && reservation.Status != "PartiallyPaid" && reservation.Status != "Paid" {
+		return 0, fmt.Errorf("only approved or paid reservations can be cancelled, reservation is %s", reservation.Status)
+	}
 
-	resp, err := client.Do(req)
+	quote, err := s.PricingService.QuoteReservation(reservation)
 	if err != nil {
 		return 0, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		logger.Error("Could not refund: %d", resp.StatusCode)
-		return 0, fmt.Errorf("Could not refund: %d", resp.StatusCode)
+	// The reservation is cancelled before any money is returned, so a refund is
+	// never sent for a reservation that stays active.
+	if err := s.ReservationRepo.CancelReservationWithReason(reservationId, reason, cancelledBy); err != nil {
+		return 0, err
+	}
+	s.cancelReminders(reservationId)
+	s.cancelPendingInstallments(reservationId)
+
+	penalty := my_models.RoundPrice(quote.Total * s.hostCancellationPenaltyPercentage / 100)
+	err = s.HostCancellationsRepo.AddHostCancellation(my_models.HostCancellation{
+		ReservationId: reservationId,
+		PropertyId:    property.Id,
+		Owner:         property.Owner,
+		CancelledBy:   cancelledBy,
+		Reason:        reason,
+		Penalty:       penalty,
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	logger.Info("Service: User ", email, "is trying to cancel reservation ", reservationId)
-	err = s.ReservationRepo.CancelReservation(reservationId)
+	if blockDates {
+		fromDate, err := my_models.ParseReservationDate(reservation.ReservedFrom)
+		if err != nil {
+			return 0, err
+		}
+		untilDate, err := my_models.ParseReservationDate(reservation.ReservedUntil)
+		if err != nil {
+			return 0, err
+		}
+		dates := []my_models.DateRange{{Start: fromDate.Format(time.DateOnly), End: untilDate.Format(time.DateOnly)}}
+		if err := s.PropertiesRepo.AddUnavailableDates(property.Id, dates); err != nil {
+			return 0, err
+		}
+	}
+
+	// Everything the tenant paid so far is given back
+	refund, err = s.refundableAmount(reservationId)
+	if err != nil {
+		return 0, err
+	}
+	if refund > 0 {
+		if _, err := s.refund(reservationId, refund); err != nil {
+			// The failed attempt is in the ledger, an Admin retries it through the refunds endpoint
+			message := fmt.Sprintf("Your reservation %s was cancelled by the host: %s. Your refund of %.2f could not be processed yet, we will send it as soon as possible", reservationId, reason, refund)
+			if mailErr := s.NotificationService.SendMail(reservation.Email, message); mailErr != nil {
+				logger.Error("Service: Error notifying tenant about host cancellation: ", mailErr)
+			}
+			return 0, fmt.Errorf("reservation %s was cancelled but its refund failed: %w", reservationId, err)
+		}
+	}
+
+	message := fmt.Sprintf("Your reservation %s was cancelled by the host: %s. You will receive a full refund of %.2f", reservationId, reason, refund)
+	if err := s.NotificationService.SendMail(reservation.Email, message); err != nil {
+		logger.Error("Service: Error notifying tenant about host cancellation: ", err)
+	}
+
+	logger.Info("Service: Reservation ", reservationId, " cancelled by ", cancelledBy, " with a penalty of ", penalty)
+	return refund, nil
+}
+
+// RefundReservation gives back part of what was captured for the reservation.
+// It can be called several times until everything captured is refunded.
+func (s *ReservationService) RefundReservation(reservationId string, amount float64) ([]my_models.Payment, error) {
+	if _, err := s.ReservationRepo.GetReservationById(reservationId); err != nil {
+		return nil, err
+	}
+
+	return s.refund(reservationId, my_models.RoundPrice(amount))
+}
+
+// refundableAmount is what was captured for the reservation and not refunded yet.
+func (s *ReservationService) refundableAmount(reservationId string) (float64, error) {
+	payments, err := s.PaymentsRepo.GetReservationPayments(reservationId)
 	if err != nil {
 		return 0, err
-	} else {
-		return refundPercentage, nil
 	}
+
+	return my_models.TotalRefundable(my_models.RefundableCharges(payments)), nil
+}
+
+// refund sends the amount back against the charges of the reservation, oldest
+// first, recording one refund per charge in the payments ledger.
+func (s *ReservationService) refund(reservationId string, amount float64) ([]my_models.Payment, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("refund amount must be greater than 0")
+	}
+
+	payments, err := s.PaymentsRepo.GetReservationPayments(reservationId)
+	if err != nil {
+		return nil, err
+	}
+
+	charges := my_models.RefundableCharges(payments)
+	if refundable := my_models.TotalRefundable(charges); amount > refundable {
+		return nil, fmt.Errorf("refund of %.2f exceeds the %.2f captured and not refunded for reservation %s", amount, refundable, reservationId)
+	}
+
+	refunds := []my_models.Payment{}
+	left := amount
+	for _, charge := range charges {
+		if left <= 0 {
+			break
+		}
+
+		part := my_models.RoundPrice(min(left, charge.Remaining))
+		refund := my_models.Payment{
+			ReservationId: reservationId,
+			ChargeId:      charge.Charge.Id,
+			Kind:          my_models.RefundPayment,
+			Amount:        part,
+			Currency:      charge.Charge.Currency,
+		}
+		refund, err := recordGatewayCall(s.PaymentsRepo, refund, func() (string, error) {
+			return s.Gateway.Refund(charge.Charge.GatewayReference, part)
+		})
+		// A refund the provider settles later already counts as refunded
+		if err != nil && !errors.Is(err, gateways.ErrPaymentPending) {
+			logger.Error("Could not refund: ", err)
+			return refunds, fmt.Errorf("Could not refund: %w", err)
+		}
+
+		refunds = append(refunds, refund)
+		left = my_models.RoundPrice(left - part)
+	}
+
+	logger.Info("Service: Refunded ", amount, " of reservation ", reservationId, " in ", len(refunds), " refunds")
+	return refunds, nil
+}
+
+func (s *ReservationService) GetGuests(reservationId string) ([]my_models.Guest, error) {
+	return s.GuestsRepo.GetGuests(reservationId)
+}
+
+// UpdateGuests replaces the guest roster of the reservation. The roster can be
+// filled in progressively but must never exceed the reserved adults and minors.
+func (s *ReservationService) UpdateGuests(email string, reservationId string, guests []my_models.Guest) ([]my_models.Guest, error) {
+	reservation, err := s.ReservationRepo.GetReservationById(reservationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if reservation.Email != email {
+		return nil, fmt.Errorf("user %s is not allowed to update the guests of reservation %s", email, reservationId)
+	}
+
+	if reservation.CheckIn != "" {
+		return nil, fmt.Errorf("reservation %s is already checked in, guests cannot be changed", reservationId)
+	}
+
+	if reservation.Status != "Pending" && reservation.Status != "Approved" && reservation.Status != "PartiallyPaid" && reservation.Status != "Paid" {
+		return nil, fmt.Errorf("guests cannot be changed for a %s reservation", reservation.Status)
+	}
+
+	for i := range guests {
+		if err := guests[i].Validate(); err != nil {
+			return nil, fmt.Errorf("guest %d: %w", i+1, err)
+		}
+	}
+
+	if err := my_models.CheckGuestRoster(guests, reservation, false); err != nil {
+		return nil, err
+	}
+
+	if err := s.GuestsRepo.ReplaceGuests(reservationId, guests); err != nil {
+		return nil, err
+	}
+
+	return s.GuestsRepo.GetGuests(reservationId)
 }
 
+// DoCheckIn registers the check in once every guest of the reservation is on the
+// roster, since guests must be registered with the authorities on arrival.
 func (s *ReservationService) DoCheckIn(reservationId string) error {
+	reservation, err := s.ReservationRepo.GetReservationById(reservationId)
+	if err != nil {
+		return err
+	}
+
+	guests, err := s.GuestsRepo.GetGuests(reservationId)
+	if err != nil {
+		return err
+	}
+
+	if err := my_models.CheckGuestRoster(guests, reservation, true); err != nil {
+		logger.Error("Service: Reservation ", reservationId, " cannot be checked in: ", err)
+		return err
+	}
+
 	return s.ReservationRepo.RegisterCheckIn(reservationId)
 }
 
 func (s *ReservationService) DoCheckOut(reservationId string) error {
-	return s.ReservationRepo.RegisterCheckOut(reservationId)
+	reservation, err := s.ReservationRepo.GetReservationById(reservationId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ReservationRepo.RegisterCheckOut(reservationId); err != nil {
+		return err
+	}
+
+	if err := s.ReminderService.ScheduleCheckOutReminders(reservation); err != nil {
+		logger.Error("Service: Error scheduling reminders of reservation ", reservationId, ": ", err)
+	}
+	return nil
 }
 
 func (s *ReservationService) GetReservationById(reservationId string) (my_models.ReservationModel, error) {
 	return s.ReservationRepo.GetReservationById(reservationId)
 }
 
+// AutoCancelReservations cancels approved reservations that were not paid
+// within the payment deadline of their country. Tenants are warned beforehand
+// by the payment reminder scheduled on approval.
 func (s *ReservationService) AutoCancelReservations() error {
-	propertiesIds, err := s.ReservationRepo.AutoCancelReservations(autoCancelDays)
+	reservations, err := s.ReservationRepo.GetUnpaidApprovedReservations()
 	if err != nil {
 		return err
 	}
 
-	for _, propertyId := range propertiesIds {
-		owner, err := s.UserRepo.GetPropertyOwner(propertyId)
+	now := time.Now().UTC()
+	for _, reservation := range reservations {
+		approvedDate, err := time.Parse(my_models.PocketTimeLayout, reservation.ApprovedDate)
+		if err != nil {
+			logger.Error("Service: Invalid approved date for reservation ", reservation.ID, ": ", err)
+			continue
+		}
+
+		deadlineHours, err := s.SettingsRepo.GetPaymentDeadlineHours(reservation.Country)
 		if err != nil {
 			return err
 		}
+		deadline := approvedDate.Add(time.Duration(deadlineHours) * time.Hour)
+
+		if now.After(deadline) {
+			if err := s.ReservationRepo.CancelReservation(reservation.ID); err != nil {
+				return err
+			}
+			s.cancelReminders(reservation.ID)
+
+			message := fmt.Sprintf("Your reservation %s was cancelled because it was not paid before %s", reservation.ID, deadline.Format(time.DateTime))
+			if err := s.NotificationService.SendMail(reservation.Email, message); err != nil {
+				logger.Error("Service: Error notifying tenant about unpaid reservation: ", err)
+			}
+			s.notifyOwner(reservation, fmt.Sprintf("Reservation %s was cancelled because it was not paid in time", reservation.ID))
+		}
+	}
+
+	return nil
+}
+
+func (s *ReservationService) ExpirePendingReservations() error {
+	reservations, err := s.ReservationRepo.ExpirePendingReservations(s.ownerResponseSlaHours)
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		message := fmt.Sprintf("Your reservation %s expired because the owner did not respond within %d hours", reservation.ID, s.ownerResponseSlaHours)
+		if err := s.NotificationService.SendMail(reservation.Email, message); err != nil {
+			logger.Error("Service: Error notifying tenant about expired reservation: ", err)
+		}
+	}
+
+	return nil
+}
+
+// ProcessNoShows marks paid reservations without a check in as NoShow and
+// refunds whatever their cancellation policy grants for a cancellation at check in.
+func (s *ReservationService) ProcessNoShows() error {
+	reservations, err := s.ReservationRepo.MarkNoShowReservations(s.noShowCutoffHours)
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		s.cancelReminders(reservation.ID)
+		s.cancelPendingInstallments(reservation.ID)
+
+		// The reservation is already a no-show, the parties are told even when the refund fails
+		refund, err := s.refundNoShow(reservation)
+		if err != nil {
+			logger.Error("Service: Error refunding no-show reservation ", reservation.ID, ": ", err)
+		}
+
+		message := fmt.Sprintf("Your reservation %s was marked as a no-show because there was no check in. Refunded amount: %.2f", reservation.ID, refund)
+		if err := s.NotificationService.SendMail(reservation.Email, message); err != nil {
+			logger.Error("Service: Error notifying tenant about no-show: ", err)
+		}
+		s.notifyOwner(reservation, fmt.Sprintf("The tenant of reservation %s did not show up", reservation.ID))
+	}
+
+	return nil
+}
+
+// refundNoShow gives back what the cancellation policy refunds for a
+// cancellation made right at check in, which is when the tenant failed to show up.
+func (s *ReservationService) refundNoShow(reservation my_models.ReservationModel) (float64, error) {
+	quote, err := s.PricingService.QuoteReservation(reservation)
+	if err != nil {
+		return 0, err
+	}
+
+	refundable, err := s.refundableAmount(reservation.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	refund := my_models.RoundPrice(refundable * quote.CancellationPolicy.NoShowRefundPercentage() / 100)
+	if refund <= 0 {
+		return 0, nil
+	}
+
+	if _, err := s.refund(reservation.ID, refund); err != nil {
+		return 0, err
+	}
+	return refund, nil
+}
 
-		logger.Info("Notification: Sending email to Owner ", owner, " about reservation being canceled :", propertyId)
+// AutoCheckOutReservations checks out the stays past their end date and asks
+// the owner to review the property.
+func (s *ReservationService) AutoCheckOutReservations() error {
+	reservations, err := s.ReservationRepo.AutoCheckOutReservations()
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		message := fmt.Sprintf("Your reservation %s was checked out automatically because the stay ended", reservation.ID)
+		if err := s.NotificationService.SendMail(reservation.Email, message); err != nil {
+			logger.Error("Service: Error notifying tenant about automatic check out: ", err)
+		}
+		s.notifyOwner(reservation, fmt.Sprintf("Reservation %s was checked out automatically and needs review", reservation.ID))
+		if err := s.ReminderService.ScheduleCheckOutReminders(reservation); err != nil {
+			logger.Error("Service: Error scheduling reminders of reservation ", reservation.ID, ": ", err)
+		}
 	}
 
 	return nil
 }
+
+func (s *ReservationService) notifyOwner(reservation my_models.ReservationModel, message string) {
+	ownerEmail, err := s.UserRepo.GetPropertyOwner(reservation.PropertyId)
+	if err != nil {
+		logger.Error("Service: Error getting owner of property ", reservation.PropertyId, ": ", err)
+		return
+	}
+
+	if err := s.NotificationService.SendMail(ownerEmail, message); err != nil {
+		logger.Error("Service: Error notifying owner: ", err)
+	}
+}
+
+func (s *ReservationService) cancelReminders(reservationId string) {
+	if err := s.ReminderService.CancelReminders(reservationId); err != nil {
+		logger.Error("Service: Error cancelling reminders of reservation ", reservationId, ": ", err)
+	}
+}
+
+func (s *ReservationService) cancelPendingInstallments(reservationId string) {
+	if err := s.PaymentSchedulesRepo.CancelPendingInstallments(reservationId); err != nil {
+		logger.Error("Service: Error cancelling pending payments of reservation ", reservationId, ": ", err)
+	}
+}
+
+// amountPaid is what the tenant paid so far for the reservation.
+func (s *ReservationService) amountPaid(reservation my_models.ReservationModel, quote my_models.PriceQuote) (float64, error) {
+	installments, err := s.PaymentSchedulesRepo.GetInstallments(reservation.ID)
+	if err != nil {
+		return 0, err
+	}
+	return my_models.AmountPaid(reservation, installments, quote.Total), nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"fmt"
+	"pocketbase_go/gateways"
+	"pocketbase_go/my_models"
+	interfaces "pocketbase_go/repos/interfaces"
+	serviceInterfaces "pocketbase_go/services/interfaces"
+	"pocketbase_go/services/mocks"
+	"testing"
+	"time"
+)
+
+const testCard = "tok_test"
+
+type stubReservationRepo struct {
+	interfaces.IReservationRepo
+	reservation my_models.ReservationModel
+}
+
+func (r *stubReservationRepo) GetReservationById(id string) (my_models.ReservationModel, error) {
+	return r.reservation, nil
+}
+
+func (r *stubReservationRepo) CancelReservation(id string) error {
+	if r.reservation.Status == "Cancelled" {
+		return fmt.Errorf("reservation is already cancelled")
+	}
+	r.reservation.Status = "Cancelled"
+	return nil
+}
+
+func (r *stubReservationRepo) UpdateReservationStatus(id string, status string) error {
+	r.reservation.Status = status
+	return nil
+}
+
+type stubReminderService struct {
+	serviceInterfaces.IReminderService
+}
+
+func (s stubReminderService) CancelReminders(reservationId string) error {
+	return nil
+}
+
+func (s stubReminderService) SchedulePaidReminders(reservation my_models.ReservationModel) error {
+	return nil
+}
+
+type stubPaymentSchedulesRepo struct {
+	interfaces.IPaymentSchedulesRepo
+}
+
+func (r stubPaymentSchedulesRepo) CancelPendingInstallments(reservationId string) error {
+	return nil
+}
+
+func (r stubPaymentSchedulesRepo) AddInstallments(reservationId string, installments []my_models.PaymentInstallment) error {
+	return nil
+}
+
+// charge collects amount on the fake gateway and returns it as a ledger entry of r1.
+func charge(gateway *gateways.FakePaymentGateway, id string, amount float64) my_models.Payment {
+	reference, _ := gateway.Charge(testCard, amount)
+	return my_models.Payment{Id: id, ReservationId: "r1", Kind: my_models.ChargePayment, Amount: amount, Status: my_models.PaymentSucceeded, GatewayReference: reference}
+}
+
+func refundAmounts(gateway *gateways.FakePaymentGateway) []float64 {
+	amounts := []float64{}
+	for _, operation := range gateway.Operations() {
+		if operation.Kind == gateways.FakeRefund {
+			amounts = append(amounts, operation.Amount)
+		}
+	}
+	return amounts
+}
+
+func newTestReservationService(reservation my_models.ReservationModel, gateway gateways.PaymentGateway, payments ...my_models.Payment) *ReservationService {
+	return &ReservationService{
+		ReservationRepo:      &stubReservationRepo{reservation: reservation},
+		PricingService:       newTestPricingService(my_models.Property{BookingPrice: 100, CleaningFee: 30}, nil),
+		ReminderService:      stubReminderService{},
+		PaymentSchedulesRepo: stubPaymentSchedulesRepo{},
+		PaymentsRepo:         mocks.NewMockPaymentsRepo(payments...),
+		Gateway:              gateway,
+	}
+}
+
+func TestRefund(t *testing.T) {
+	cases := []struct {
+		name     string
+		payments func(*gateways.FakePaymentGateway) []my_models.Payment
+		amount   float64
+		refunds  []float64
+		fails    bool
+	}{
+		{"split across charges", func(g *gateways.FakePaymentGateway) []my_models.Payment {
+			return []my_models.Payment{charge(g, "c1", 60), charge(g, "c2", 40)}
+		}, 80, []float64{60, 20}, false},
+		{"after a partial refund", func(g *gateways.FakePaymentGateway) []my_models.Payment {
+			first := charge(g, "c1", 60)
+			g.Refund(first.GatewayReference, 30)
+			refunded := my_models.Payment{ReservationId: "r1", ChargeId: "c1", Kind: my_models.RefundPayment, Amount: 30, Status: my_models.PaymentSucceeded}
+			return []my_models.Payment{first, refunded, charge(g, "c2", 40)}
+		}, 70, []float64{30, 30, 40}, false},
+		{"above what was captured", func(g *gateways.FakePaymentGateway) []my_models.Payment {
+			return []my_models.Payment{charge(g, "c1", 60), charge(g, "c2", 40)}
+		}, 100.01, []float64{}, true},
+		{"unpaid reservation", func(g *gateways.FakePaymentGateway) []my_models.Payment {
+			return []my_models.Payment{
+				{Id: "c1", ReservationId: "r1", Kind: my_models.ChargePayment, Amount: 100, Status: my_models.PaymentFailed},
+				{Id: "c2", ReservationId: "r1", Kind: my_models.ChargePayment, Amount: 100, Status: my_models.PaymentPending},
+			}
+		}, 10, []float64{}, true},
+	}
+	for _, c := range cases {
+		gateway := gateways.NewFakePaymentGateway()
+		service := newTestReservationService(my_models.ReservationModel{ID: "r1"}, gateway, c.payments(gateway)...)
+
+		_, err := service.refund("r1", c.amount)
+		if (err != nil) != c.fails {
+			t.Errorf("%s: expected failure %v, got %v", c.name, c.fails, err)
+		}
+		if refunds := refundAmounts(gateway); fmt.Sprint(refunds) != fmt.Sprint(c.refunds) {
+			t.Errorf("%s: expected refunds %v, got %v", c.name, c.refunds, refunds)
+		}
+	}
+}
+
+func TestCancelReservation(t *testing.T) {
+	reservation := func(status string, daysAhead int) my_models.ReservationModel {
+		from := time.Now().AddDate(0, 0, daysAhead)
+		return my_models.ReservationModel{
+			ID:            "r1",
+			Email:         "tenant@mail.com",
+			PropertyId:    "property",
+			Country:       "UY",
+			Status:        status,
+			ReservedFrom:  from.Format(time.DateOnly),
+			ReservedUntil: from.AddDate(0, 0, 3).Format(time.DateOnly),
+		}
+	}
+
+	cases := []struct {
+		name        string
+		reservation my_models.ReservationModel
+		paid        float64
+		percentage  float64
+		refunds     []float64
+		fails       bool
+	}{
+		{"paid early", reservation("Paid", 30), 100, 100, []float64{100}, false},
+		{"paid late", reservation("Paid", 2), 100, 50, []float64{50}, false},
+		{"partially paid", reservation("PartiallyPaid", 30), 40, 100, []float64{40}, false},
+		{"approved and unpaid", reservation("Approved", 30), 0, 100, []float64{}, false},
+		{"pending", reservation("Pending", 30), 0, 0, []float64{}, true},
+		{"already cancelled", reservation("Cancelled", 30), 100, 0, []float64{}, true},
+		{"already started", reservation("Paid", -1), 100, 0, []float64{}, true},
+	}
+	for _, c := range cases {
+		gateway := gateways.NewFakePaymentGateway()
+		payments := []my_models.Payment{}
+		if c.paid > 0 {
+			payments = append(payments, charge(gateway, "c1", c.paid))
+		}
+		service := newTestReservationService(c.reservation, gateway, payments...)
+
+		percentage, err := service.CancelReservation("tenant@mail.com", "r1")
+		if (err != nil) != c.fails {
+			t.Errorf("%s: expected failure %v, got %v", c.name, c.fails, err)
+		}
+		if percentage != c.percentage {
+			t.Errorf("%s: expected %v percent refunded, got %v", c.name, c.percentage, percentage)
+		}
+		if refunds := refundAmounts(gateway); fmt.Sprint(refunds) != fmt.Sprint(c.refunds) {
+			t.Errorf("%s: expected refunds %v, got %v", c.name, c.refunds, refunds)
+		}
+	}
+}
+
+func TestCancelReservationTwiceRefundsOnce(t *testing.T) {
+	from := time.Now().AddDate(0, 0, 30)
+	reservation := my_models.ReservationModel{
+		ID:            "r1",
+		Email:         "tenant@mail.com",
+		PropertyId:    "property",
+		Country:       "UY",
+		Status:        "Paid",
+		ReservedFrom:  from.Format(time.DateOnly),
+		ReservedUntil: from.AddDate(0, 0, 3).Format(time.DateOnly),
+	}
+	gateway := gateways.NewFakePaymentGateway()
+	service := newTestReservationService(reservation, gateway, charge(gateway, "c1", 100))
+
+	if _, err := service.CancelReservation("tenant@mail.com", "r1"); err != nil {
+		t.Fatalf("expected the first cancellation to succeed, got %v", err)
+	}
+	if _, err := service.CancelReservation("tenant@mail.com", "r1"); err == nil {
+		t.Errorf("expected the second cancellation to fail")
+	}
+
+	if refunds := refundAmounts(gateway); len(refunds) != 1 {
+		t.Errorf("expected a single refund, got %v", refunds)
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"pocketbase_go/logger"
+	"pocketbase_go/my_models"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var reservationExportHeader = []string{
+	"id", "property", "status", "reserved_from", "reserved_until", "name", "last_name", "email",
+	"phone", "document", "country", "adults", "minors", "check_in", "check_out", "total",
+}
+
+// reservationExportRows flattens reservations into spreadsheet rows. The total
+// column is left empty when a reservation can no longer be quoted.
+func (s *ReservationService) reservationExportRows(reservations []my_models.ReservationModel) [][]string {
+	rows := make([][]string, 0, len(reservations)+1)
+	rows = append(rows, reservationExportHeader)
+
+	for _, reservation := range reservations {
+		total := ""
+		if quote, err := s.PricingService.QuoteReservation(reservation); err == nil {
+			total = strconv.FormatFloat(quote.Total, 'f', 2, 64)
+		} else {
+			logger.Warn("Service: Could not quote reservation ", reservation.ID, " for export: ", err)
+		}
+
+		rows = append(rows, []string{
+			reservation.ID, reservation.PropertyId, reservation.Status, reservation.ReservedFrom, reservation.ReservedUntil,
+			reservation.Name, reservation.LastName, reservation.Email, reservation.Phone, reservation.Document,
+			reservation.Country, strconv.Itoa(reservation.Adults), strconv.Itoa(reservation.Minors),
+			reservation.CheckIn, reservation.CheckOut, total,
+		})
+	}
+
+	return rows
+}
+
+func writeReservationsCSV(rows [][]string) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func writeReservationsXLSX(rows [][]string) ([]byte, error) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	sheet := file.GetSheetName(0)
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(row))
+		for j, value := range row {
+			values[j] = value
+		}
+		if err := file.SetSheetRow(sheet, cell, &values); err != nil {
+			return nil, err
+		}
+	}
+
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func writeReservationsExport(rows [][]string, format string) ([]byte, error) {
+	switch format {
+	case my_models.CsvExportFormat:
+		return writeReservationsCSV(rows)
+	case my_models.XlsxExportFormat:
+		return writeReservationsXLSX(rows)
+	default:
+		return nil, fmt.Errorf("invalid export format %s, valid formats are %s and %s", format, my_models.CsvExportFormat, my_models.XlsxExportFormat)
+	}
+}
@@ -1,12 +1,40 @@
+// Payment-Module simulates a card processor so payments can be tested end to
+// end without a real one. It is configured through environment variables:
+//
+//	PORT                     port to listen on, 8085 by default
+//	SIMULATOR_STATE_FILE     keeps tokens, authorizations and charges in this file
+//	                         across restarts, they live in memory when unset
+//	SIMULATOR_MAX_LATENCY_MS upper bound of the random processing time, 5000 by default
+//	SIMULATOR_TIMEOUT_MS     how long the timeout card hangs, 30000 by default
+//	WEBHOOK_URL              where the outcome of async calls is posted
+//	WEBHOOK_SECRET           key of the HMAC signature of the webhooks
+//
+// Magic card numbers make charges and authorizations fail:
+//
+//	4000000000000002 card declined (402)
+//	4000000000009995 insufficient funds (402)
+//	4000000000000119 processor error (500)
+//	4000000000000101 hangs for SIMULATOR_TIMEOUT_MS and answers 504
+//
+// The CVV is only checked when a card is tokenized, tokens only keep the masked
+// number, the brand and the expiry of the card.
 package main
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,9 +45,449 @@ type CardInformation struct {
 	ExpDate    string `json:"expDate"`
 }
 
+// StoredCard is what a token keeps of the card. Outcome names the scripted
+// behavior of a magic card.
+type StoredCard struct {
+	MaskedNumber string `json:"maskedNumber"`
+	Brand        string `json:"brand"`
+	ExpDate      string `json:"expDate"`
+	Outcome      string `json:"outcome,omitempty"`
+}
+
 type PaymentRequest struct {
-	CardInformation CardInformation `json:"cardInformation"`
-	Price           int             `json:"price"`
+	CardToken string  `json:"cardToken"`
+	Price     float64 `json:"price"`
+	Async     bool    `json:"async"`
+}
+
+type TokenResponse struct {
+	Token string `json:"token"`
+	Brand string `json:"brand"`
+	Last4 string `json:"last4"`
+}
+
+type RefundRequest struct {
+	ChargeId string  `json:"chargeId"`
+	Amount   float64 `json:"amount"`
+	Async    bool    `json:"async"`
+}
+
+type CaptureRequest struct {
+	AuthorizationId string  `json:"authorizationId"`
+	Amount          float64 `json:"amount"`
+}
+
+type PaymentResponse struct {
+	Id     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// WebhookEvent reports the outcome of a charge or refund settled in the
+// background. Reference is the id returned when the call was accepted.
+type WebhookEvent struct {
+	Id        string  `json:"id"`
+	Type      string  `json:"type"`
+	Reference string  `json:"reference"`
+	Status    string  `json:"status"`
+	Amount    float64 `json:"amount"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+const (
+	statusPending   = "pending"
+	statusSucceeded = "succeeded"
+	statusFailed    = "failed"
+)
+
+// Charge is money taken from a card, directly or by capturing an authorization.
+// Refunds can never add up to more than what was captured.
+type Charge struct {
+	Id       string    `json:"id"`
+	Amount   float64   `json:"amount"`
+	Captured float64   `json:"captured"`
+	Refunded float64   `json:"refunded"`
+	Status   string    `json:"status"`
+	Reason   string    `json:"reason,omitempty"`
+	Brand    string    `json:"brand"`
+	Last4    string    `json:"last4"`
+	Refunds  []*Refund `json:"refunds"`
+	Created  time.Time `json:"created"`
+}
+
+type Refund struct {
+	Id      string    `json:"id"`
+	Amount  float64   `json:"amount"`
+	Status  string    `json:"status"`
+	Created time.Time `json:"created"`
+}
+
+type Authorization struct {
+	Id        string  `json:"id"`
+	CardToken string  `json:"cardToken"`
+	Amount    float64 `json:"amount"`
+}
+
+type simulatorState struct {
+	Cards          map[string]StoredCard     `json:"cards"`
+	Authorizations map[string]*Authorization `json:"authorizations"`
+	Charges        map[string]*Charge        `json:"charges"`
+}
+
+// outcome is how the processor answers a call for a magic card.
+type outcome struct {
+	status  int
+	message string
+	hang    bool
+}
+
+var magicCards = map[string]string{
+	"4000000000000002": "card_declined",
+	"4000000000009995": "insufficient_funds",
+	"4000000000000119": "processor_error",
+	"4000000000000101": "processor_timeout",
+}
+
+var outcomes = map[string]outcome{
+	"card_declined":      {status: http.StatusPaymentRequired, message: "Card declined"},
+	"insufficient_funds": {status: http.StatusPaymentRequired, message: "Insufficient funds"},
+	"processor_error":    {status: http.StatusInternalServerError, message: "Processor error"},
+	"processor_timeout":  {status: http.StatusGatewayTimeout, message: "Processor timeout", hang: true},
+}
+
+const webhookMaxAttempts = 5
+
+var (
+	// Cards are only known here, callers charge them through their token
+	stateMutex sync.Mutex
+	state      = simulatorState{
+		Cards:          map[string]StoredCard{},
+		Authorizations: map[string]*Authorization{},
+		Charges:        map[string]*Charge{},
+	}
+	stateFile string
+
+	maxLatency     = 5 * time.Second
+	timeoutLatency = 30 * time.Second
+
+	// Async calls are only accepted when both are set, through WEBHOOK_URL and WEBHOOK_SECRET
+	webhookUrl    string
+	webhookSecret string
+	// An API that does not answer must not hold a delivery forever, the attempt is retried
+	webhookClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+func newId(prefix string) string {
+	return fmt.Sprintf("%s_%d%d", prefix, time.Now().UnixNano(), rand.Intn(1000))
+}
+
+func writeResponse(w http.ResponseWriter, id string, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PaymentResponse{Id: id, Status: status})
+}
+
+func writePending(w http.ResponseWriter, id string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(PaymentResponse{Id: id, Status: statusPending})
+}
+
+func simulateLatency() {
+	if maxLatency > 0 {
+		time.Sleep(time.Duration(rand.Int63n(int64(maxLatency) + 1)))
+	}
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// loadState reads the state file when there is one.
+func loadState() {
+	if stateFile == "" {
+		return
+	}
+
+	data, err := os.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &state)
+	}
+	if err != nil {
+		log.Fatalf("Could not load %s: %v", stateFile, err)
+	}
+}
+
+// saveState writes the state file, it must be called holding stateMutex.
+func saveState() {
+	if stateFile == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err == nil {
+		// Written aside and renamed so a crash never leaves a truncated file
+		err = os.WriteFile(stateFile+".tmp", data, 0600)
+	}
+	if err == nil {
+		err = os.Rename(stateFile+".tmp", stateFile)
+	}
+	if err != nil {
+		fmt.Printf("Could not save %s: %v\n", stateFile, err)
+	}
+}
+
+// sendWebhook posts the event signed with HMAC-SHA256 over "<timestamp>.<body>",
+// retrying with a growing delay until the API accepts it.
+func sendWebhook(event WebhookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		fmt.Printf("Webhook %s not sent: %v\n", event.Id, err)
+		return
+	}
+
+	delay := time.Second
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(webhookSecret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+
+		req, err := http.NewRequest("POST", webhookUrl, bytes.NewBuffer(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Payment-Signature", fmt.Sprintf("t=%s,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil))))
+
+			var resp *http.Response
+			resp, err = webhookClient.Do(req)
+			if err == nil {
+				resp.Body.Close()
+				if resp.StatusCode < 300 {
+					fmt.Printf("Webhook %s delivered\n", event.Id)
+					return
+				}
+				err = fmt.Errorf("status %d", resp.StatusCode)
+			}
+		}
+
+		fmt.Printf("Webhook %s attempt %d failed: %v\n", event.Id, attempt, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func asyncEnabled(requested bool) bool {
+	return requested && webhookUrl != "" && webhookSecret != ""
+}
+
+func validateCard(cardInfo CardInformation) string {
+	if cardInfo.CardNumber == "" || cardInfo.Name == "" || cardInfo.CVV == "" || cardInfo.ExpDate == "" {
+		return "Missing info"
+	}
+
+	if len(cardInfo.CardNumber) < 13 || len(cardInfo.CardNumber) > 19 || !isDigits(cardInfo.CardNumber) || !luhnValid(cardInfo.CardNumber) {
+		return "Invalid card number"
+	}
+
+	cvvLength := 3
+	if cardBrand(cardInfo.CardNumber) == "amex" {
+		cvvLength = 4
+	}
+	if len(cardInfo.CVV) != cvvLength || !isDigits(cardInfo.CVV) {
+		return "Invalid CVV"
+	}
+
+	return validateExpiration(cardInfo.ExpDate)
+}
+
+// validateExpiration is checked again on every charge since a token outlives
+// the request that created it.
+func validateExpiration(expDate string) string {
+	expectedDateFormat := "2006-01"
+	date, err := time.Parse(expectedDateFormat, expDate)
+	// Cards are valid until the end of their expiration month
+	if err != nil || date.AddDate(0, 1, 0).Before(time.Now()) {
+		return "Invalid date"
+	}
+
+	return ""
+}
+
+func isDigits(value string) bool {
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// luhnValid checks the card number check digit.
+func luhnValid(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// cardBrand tells the network of the card from its leading digits.
+func cardBrand(number string) string {
+	prefix := func(length int) int {
+		if len(number) < length {
+			return -1
+		}
+		value, _ := strconv.Atoi(number[:length])
+		return value
+	}
+
+	switch {
+	case prefix(1) == 4:
+		return "visa"
+	case prefix(2) >= 51 && prefix(2) <= 55, prefix(4) >= 2221 && prefix(4) <= 2720:
+		return "mastercard"
+	case prefix(2) == 34 || prefix(2) == 37:
+		return "amex"
+	case prefix(4) == 6011 || prefix(2) == 65, prefix(3) >= 644 && prefix(3) <= 649:
+		return "discover"
+	case prefix(4) >= 3528 && prefix(4) <= 3589:
+		return "jcb"
+	case prefix(2) == 36 || prefix(2) == 38 || prefix(2) == 39, prefix(3) >= 300 && prefix(3) <= 305:
+		return "diners"
+	default:
+		return "unknown"
+	}
+}
+
+// storeCard keeps what is needed to charge the card later, never its full
+// number nor its CVV.
+func storeCard(cardInfo CardInformation) StoredCard {
+	number := cardInfo.CardNumber
+	return StoredCard{
+		MaskedNumber: strings.Repeat("*", len(number)-4) + last4(number),
+		Brand:        cardBrand(number),
+		ExpDate:      cardInfo.ExpDate,
+		Outcome:      magicCards[number],
+	}
+}
+
+// resolveCard returns the card behind the token, or why it cannot be charged.
+func resolveCard(token string) (StoredCard, string) {
+	stateMutex.Lock()
+	card, ok := state.Cards[token]
+	stateMutex.Unlock()
+
+	if !ok {
+		return StoredCard{}, "Invalid card token"
+	}
+	if message := validateExpiration(card.ExpDate); message != "" {
+		return StoredCard{}, message
+	}
+	return card, ""
+}
+
+// processorFailure answers like a failing processor for the cards scripted to
+// error or hang, which never reach the point of creating a charge.
+func processorFailure(w http.ResponseWriter, card StoredCard) bool {
+	result, ok := outcomes[card.Outcome]
+	if !ok || result.status < http.StatusInternalServerError {
+		return false
+	}
+
+	if result.hang {
+		time.Sleep(timeoutLatency)
+	}
+	http.Error(w, result.message, result.status)
+	return true
+}
+
+// decline tells why the processor refuses the card, if it does.
+func decline(card StoredCard) (int, string) {
+	result, ok := outcomes[card.Outcome]
+	if !ok || result.status >= http.StatusInternalServerError {
+		return 0, ""
+	}
+	return result.status, result.message
+}
+
+func last4(number string) string {
+	if len(number) < 4 {
+		return number
+	}
+	return number[len(number)-4:]
+}
+
+func newCharge(card StoredCard, amount float64) *Charge {
+	return &Charge{
+		Id:      newId("ch"),
+		Amount:  amount,
+		Status:  statusPending,
+		Brand:   card.Brand,
+		Last4:   last4(card.MaskedNumber),
+		Refunds: []*Refund{},
+		Created: time.Now().UTC(),
+	}
+}
+
+// settleCharge stores the final status of the charge and returns the event
+// reporting it, read while holding the lock.
+func settleCharge(charge *Charge, reason string) WebhookEvent {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	charge.Status = statusSucceeded
+	charge.Captured = charge.Amount
+	if reason != "" {
+		charge.Status = statusFailed
+		charge.Captured = 0
+		charge.Reason = reason
+	}
+	state.Charges[charge.Id] = charge
+	saveState()
+	return WebhookEvent{Id: newId("evt"), Type: "charge", Reference: charge.Id, Status: charge.Status, Amount: charge.Amount, Reason: charge.Reason}
+}
+
+func tokenizeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		var cardInfo CardInformation
+
+		err := json.NewDecoder(r.Body).Decode(&cardInfo)
+		if err != nil {
+			http.Error(w, "Error", http.StatusBadRequest)
+			return
+		}
+
+		if message := validateCard(cardInfo); message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+
+		token := newId("tok")
+		card := storeCard(cardInfo)
+		stateMutex.Lock()
+		state.Cards[token] = card
+		saveState()
+		stateMutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(TokenResponse{
+			Token: token,
+			Brand: card.Brand,
+			Last4: last4(card.MaskedNumber),
+		})
+	} else {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+	}
 }
 
 func handlerFunc(w http.ResponseWriter, r *http.Request) {
@@ -32,42 +500,130 @@ func handlerFunc(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		cardInfo := paymentRequest.CardInformation
-		if cardInfo.CardNumber == "" || cardInfo.Name == "" || cardInfo.CVV == "" || cardInfo.ExpDate == "" {
-			http.Error(w, "Missing info", http.StatusBadRequest)
+		card, message := resolveCard(paymentRequest.CardToken)
+		if message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+		if paymentRequest.Price <= 0 {
+			http.Error(w, "Invalid amount", http.StatusBadRequest)
+			return
+		}
+		if processorFailure(w, card) {
+			return
+		}
+
+		charge := newCharge(card, roundAmount(paymentRequest.Price))
+		status, reason := decline(card)
+
+		if asyncEnabled(paymentRequest.Async) {
+			stateMutex.Lock()
+			state.Charges[charge.Id] = charge
+			saveState()
+			stateMutex.Unlock()
+			writePending(w, charge.Id)
+
+			go func() {
+				simulateLatency()
+				sendWebhook(settleCharge(charge, reason))
+			}()
+			return
+		}
+
+		simulateLatency()
+		settleCharge(charge, reason)
+		if reason != "" {
+			http.Error(w, reason, status)
+			return
+		}
+
+		fmt.Printf("Payment processed\n")
+		writeResponse(w, charge.Id, "processed")
+	} else {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+func authorizeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		var paymentRequest PaymentRequest
+
+		err := json.NewDecoder(r.Body).Decode(&paymentRequest)
+		if err != nil {
+			http.Error(w, "Error", http.StatusBadRequest)
 			return
 		}
 
-		if len(cardInfo.CardNumber) != 16 {
-			http.Error(w, "Invalid card number", http.StatusBadRequest)
+		card, message := resolveCard(paymentRequest.CardToken)
+		if message != "" {
+			http.Error(w, message, http.StatusBadRequest)
 			return
 		}
-		number, err := strconv.Atoi(cardInfo.CardNumber)
-		if err != nil || number < 0 {
-			http.Error(w, "Invalid card number", http.StatusBadRequest)
+		if paymentRequest.Price <= 0 {
+			http.Error(w, "Invalid amount", http.StatusBadRequest)
+			return
+		}
+		if processorFailure(w, card) {
 			return
 		}
 
-		if len(cardInfo.CVV) != 3 {
-			http.Error(w, "Invalid CVV", http.StatusBadRequest)
+		simulateLatency()
+		if status, reason := decline(card); reason != "" {
+			http.Error(w, reason, status)
 			return
 		}
-		cvv, err := strconv.Atoi(cardInfo.CVV)
-		if err != nil || cvv < 0 {
-			http.Error(w, "Invalid CVV", http.StatusBadRequest)
+
+		authorization := &Authorization{Id: newId("auth"), CardToken: paymentRequest.CardToken, Amount: roundAmount(paymentRequest.Price)}
+		stateMutex.Lock()
+		state.Authorizations[authorization.Id] = authorization
+		saveState()
+		stateMutex.Unlock()
+
+		fmt.Printf("Payment authorized\n")
+		writeResponse(w, authorization.Id, "authorized")
+	} else {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+func captureHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		var captureRequest CaptureRequest
+
+		err := json.NewDecoder(r.Body).Decode(&captureRequest)
+		if err != nil {
+			http.Error(w, "Error", http.StatusBadRequest)
 			return
 		}
 
-		expectedDateFormat := "2006-01"
-		date, err := time.Parse(expectedDateFormat, cardInfo.ExpDate)
-		if err != nil || date.Before(time.Now()) {
-			http.Error(w, "Invalid date", http.StatusBadRequest)
+		amount := roundAmount(captureRequest.Amount)
+		var card StoredCard
+		stateMutex.Lock()
+		authorization, ok := state.Authorizations[captureRequest.AuthorizationId]
+		if ok {
+			card = state.Cards[authorization.CardToken]
+			if amount > 0 && amount <= authorization.Amount {
+				delete(state.Authorizations, captureRequest.AuthorizationId)
+				saveState()
+			}
+		}
+		stateMutex.Unlock()
+
+		if !ok {
+			http.Error(w, "Authorization not found", http.StatusNotFound)
 			return
 		}
+		if amount <= 0 || amount > authorization.Amount {
+			http.Error(w, "Invalid amount", http.StatusBadRequest)
+			return
+		}
+
+		simulateLatency()
+		charge := newCharge(card, amount)
+		settleCharge(charge, "")
 
-		time.Sleep(time.Duration(rand.Intn(5)) * time.Second)
-		fmt.Printf("Payment processed")
-		fmt.Fprintf(w, "Payment processed")
+		fmt.Printf("Payment captured\n")
+		writeResponse(w, charge.Id, "processed")
 	} else {
 		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
 	}
@@ -75,18 +631,118 @@ func handlerFunc(w http.ResponseWriter, r *http.Request) {
 
 func refundHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
-		time.Sleep(time.Duration(rand.Intn(5)) * time.Second)
+		var refundRequest RefundRequest
+
+		err := json.NewDecoder(r.Body).Decode(&refundRequest)
+		if err != nil {
+			http.Error(w, "Error", http.StatusBadRequest)
+			return
+		}
+
+		// The amount is reserved right away so concurrent refunds cannot exceed the capture
+		amount := roundAmount(refundRequest.Amount)
+		refund := &Refund{Id: newId("re"), Amount: amount, Status: statusPending, Created: time.Now().UTC()}
+		stateMutex.Lock()
+		status, message := http.StatusOK, ""
+		charge, ok := state.Charges[refundRequest.ChargeId]
+		switch {
+		case !ok:
+			status, message = http.StatusNotFound, "Charge not found"
+		case charge.Status != statusSucceeded:
+			status, message = http.StatusBadRequest, fmt.Sprintf("Charge is %s, only succeeded charges can be refunded", charge.Status)
+		case amount <= 0:
+			status, message = http.StatusBadRequest, "Invalid amount"
+		case roundAmount(charge.Refunded+amount) > charge.Captured:
+			status, message = http.StatusBadRequest, fmt.Sprintf("Refund exceeds the %.2f captured and not refunded", roundAmount(charge.Captured-charge.Refunded))
+		default:
+			charge.Refunded = roundAmount(charge.Refunded + amount)
+			charge.Refunds = append(charge.Refunds, refund)
+			saveState()
+		}
+		stateMutex.Unlock()
+
+		if message != "" {
+			http.Error(w, message, status)
+			return
+		}
+
+		settleRefund := func() {
+			stateMutex.Lock()
+			refund.Status = statusSucceeded
+			saveState()
+			stateMutex.Unlock()
+		}
+
+		if asyncEnabled(refundRequest.Async) {
+			writePending(w, refund.Id)
 
-		fmt.Fprintf(w, "Refund processed")
+			go func() {
+				simulateLatency()
+				settleRefund()
+				sendWebhook(WebhookEvent{Id: newId("evt"), Type: "refund", Reference: refund.Id, Status: statusSucceeded, Amount: amount})
+			}()
+			return
+		}
+
+		simulateLatency()
+		settleRefund()
+		writeResponse(w, refund.Id, "refunded")
 	} else {
 		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
 	}
 
 }
 
+func chargeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		id := strings.TrimPrefix(r.URL.Path, "/charges/")
+
+		stateMutex.Lock()
+		charge, ok := state.Charges[id]
+		var body []byte
+		if ok {
+			body, _ = json.Marshal(charge)
+		}
+		stateMutex.Unlock()
+
+		if !ok {
+			http.Error(w, "Charge not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	} else {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return time.Duration(value) * time.Millisecond
+}
+
 func main() {
 	fmt.Printf("Service on")
+	webhookUrl = os.Getenv("WEBHOOK_URL")
+	webhookSecret = os.Getenv("WEBHOOK_SECRET")
+	stateFile = os.Getenv("SIMULATOR_STATE_FILE")
+	maxLatency = envDuration("SIMULATOR_MAX_LATENCY_MS", maxLatency)
+	timeoutLatency = envDuration("SIMULATOR_TIMEOUT_MS", timeoutLatency)
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8085"
+	}
+	loadState()
+
 	http.HandleFunc("/", handlerFunc)
+	http.HandleFunc("/tokenize", tokenizeHandler)
+	http.HandleFunc("/authorize", authorizeHandler)
+	http.HandleFunc("/capture", captureHandler)
 	http.HandleFunc("/refund", refundHandler)
-	log.Fatal(http.ListenAndServe(":8085", nil))
-}
\ No newline at end of file
+	http.HandleFunc("/charges/", chargeHandler)
+	log.Fatal(http.ListenAndServe(":"+port, nil))
+}